@@ -0,0 +1,461 @@
+package fuego
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Codec decodes request bodies and encodes responses in one or more media types.
+// Codecs are registered on the server with [WithCodec], and are used for decoding the request bodies
+// by their Content-Type, for negotiating the response format with the Accept header (see [WithContentNegotiation]),
+// and for the content types documented in the OpenAPI spec.
+//
+// Codecs only supporting some types can also implement [CodecSupport].
+type Codec interface {
+	// MediaTypes returns the media types handled by the codec. The first one is documented in the OpenAPI spec.
+	MediaTypes() []string
+	// Decode decodes the request body into v, a non-nil pointer.
+	// Errors not implementing [ErrorWithStatus] are returned as [BadRequestError].
+	Decode(r *http.Request, v any, options DecodeOptions) error
+	// Encode writes v as the response body, with its Content-Type header.
+	Encode(w http.ResponseWriter, r *http.Request, v any) error
+	// EncodeError writes the error as the response body, with its Content-Type header and status code.
+	EncodeError(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// CodecSupport can be implemented by a [Codec] only supporting some types.
+// The codec is then neither used nor documented for the other ones.
+type CodecSupport interface {
+	// CanDecode reports whether request bodies can be decoded into the type.
+	CanDecode(t reflect.Type) bool
+	// CanEncode reports whether the type can be encoded. It is called with the error interface type for errors.
+	CanEncode(t reflect.Type) bool
+}
+
+// CodecFieldTag can be implemented by a [Codec] naming the fields by another struct tag than json, like xml.
+// The fields of the validation errors of the request bodies decoded by the codec are named by this tag.
+type CodecFieldTag interface {
+	// FieldTag returns the struct tag naming the fields in the format of the codec.
+	FieldTag() string
+}
+
+// DecodeOptions are the options given to [Codec.Decode].
+type DecodeOptions struct {
+	DisallowUnknownFields bool
+	MaxFileSize           int64 // Maximum size of each uploaded file. No limit if 0.
+}
+
+// errorType is the type given to [CodecSupport.CanEncode] for errors.
+var errorType = reflect.TypeFor[error]()
+
+var (
+	_ Codec        = JSONCodec{}
+	_ Codec        = XMLCodec{}
+	_ Codec        = YAMLCodec{}
+	_ Codec        = TextCodec{}
+	_ Codec        = HTMLCodec{}
+	_ Codec        = FormCodec{}
+	_ Codec        = BinaryCodec{}
+	_ CodecSupport = XMLCodec{}
+	_ CodecSupport = TextCodec{}
+	_ CodecSupport = HTMLCodec{}
+	_ CodecSupport = FormCodec{}
+	_ CodecSupport = BinaryCodec{}
+
+	_ CodecFieldTag = XMLCodec{}
+	_ CodecFieldTag = YAMLCodec{}
+	_ CodecFieldTag = FormCodec{}
+)
+
+// JSONCodec is the application/json codec, using [SendJSON] and [SendJSONError].
+type JSONCodec struct{}
+
+func (JSONCodec) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+func (JSONCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	dec := json.NewDecoder(r.Body)
+	if options.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	return ignoreEOF(dec.Decode(v))
+}
+
+func (JSONCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return SendJSON(w, r, v)
+}
+
+func (JSONCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendJSONError(w, r, err)
+}
+
+// XMLCodec is the application/xml codec, using [SendXML] and [SendXMLError]. Maps are not supported.
+type XMLCodec struct{}
+
+func (XMLCodec) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+func (XMLCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	dec := xml.NewDecoder(r.Body)
+	if options.DisallowUnknownFields {
+		dec.Strict = true
+	}
+	return ignoreEOF(dec.Decode(v))
+}
+
+func (XMLCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return SendXML(w, r, v)
+}
+
+func (XMLCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendXMLError(w, r, err)
+}
+
+func (XMLCodec) CanDecode(t reflect.Type) bool {
+	return indirectType(t).Kind() != reflect.Map
+}
+
+func (XMLCodec) CanEncode(t reflect.Type) bool {
+	return indirectType(t).Kind() != reflect.Map
+}
+
+func (XMLCodec) FieldTag() string {
+	return "xml"
+}
+
+// YAMLCodec is the YAML codec (RFC 9512), using [SendYAML] and [SendYAMLError].
+type YAMLCodec struct{}
+
+func (YAMLCodec) MediaTypes() []string {
+	return []string{"application/x-yaml", "application/yaml", "text/yaml"}
+}
+
+func (YAMLCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	dec := yaml.NewDecoder(r.Body)
+	if options.DisallowUnknownFields {
+		dec.KnownFields(true)
+	}
+	return ignoreEOF(dec.Decode(v))
+}
+
+func (YAMLCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return SendYAML(w, r, v)
+}
+
+func (YAMLCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendYAMLError(w, r, err)
+}
+
+func (YAMLCodec) FieldTag() string {
+	return "yaml"
+}
+
+// TextCodec is the text/plain codec, using [SendText] and [SendTextError]. Only strings are supported.
+type TextCodec struct{}
+
+func (TextCodec) MediaTypes() []string {
+	return []string{"text/plain"}
+}
+
+func (TextCodec) Decode(r *http.Request, v any, _ DecodeOptions) error {
+	target := reflect.ValueOf(v).Elem()
+	if !(TextCodec{}).CanDecode(target.Type()) {
+		return fmt.Errorf("cannot decode text into %s", target.Type())
+	}
+
+	text, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	target.Set(reflect.ValueOf(string(text)).Convert(target.Type()))
+
+	return nil
+}
+
+func (TextCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return SendText(w, r, v)
+}
+
+func (TextCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendTextError(w, r, err)
+}
+
+func (TextCodec) CanDecode(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Interface && t.NumMethod() == 0
+}
+
+func (TextCodec) CanEncode(t reflect.Type) bool {
+	return t == errorType || indirectType(t).Kind() == reflect.String
+}
+
+// HTMLCodec is the text/html codec, using [SendHTML] and [SendHTMLError].
+// It only encodes strings, [HTML] and the types implementing [CtxRenderer] or [Renderer].
+type HTMLCodec struct{}
+
+func (HTMLCodec) MediaTypes() []string {
+	return []string{"text/html"}
+}
+
+func (HTMLCodec) Decode(*http.Request, any, DecodeOptions) error {
+	return errors.New("cannot decode text/html request body")
+}
+
+func (HTMLCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	return SendHTML(w, r, v)
+}
+
+func (HTMLCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendHTMLError(w, r, err)
+}
+
+func (HTMLCodec) CanDecode(reflect.Type) bool {
+	return false
+}
+
+func (HTMLCodec) CanEncode(t reflect.Type) bool {
+	if t == errorType || t.Kind() == reflect.String {
+		return true
+	}
+	for _, renderer := range []reflect.Type{reflect.TypeFor[CtxRenderer](), reflect.TypeFor[Renderer]()} {
+		if t.Implements(renderer) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormCodec decodes application/x-www-form-urlencoded and multipart/form-data request bodies,
+// like [ReadURLEncoded] and [ReadMultipart]. It does not encode responses.
+type FormCodec struct{}
+
+func (FormCodec) MediaTypes() []string {
+	return []string{"application/x-www-form-urlencoded", "multipart/form-data"}
+}
+
+func (FormCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		err := parseMultipartForm(r, options.MaxFileSize)
+		if err != nil {
+			return err
+		}
+		return decodeFormValues(v, mediaType, r.MultipartForm.Value, r.MultipartForm.File, options)
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		return fmt.Errorf("cannot parse form: %w", err)
+	}
+	return decodeFormValues(v, "x-www-form-urlencoded", r.PostForm, nil, options)
+}
+
+func (FormCodec) Encode(http.ResponseWriter, *http.Request, any) error {
+	return errors.New("cannot encode forms")
+}
+
+func (FormCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendJSONError(w, r, err)
+}
+
+func (FormCodec) CanDecode(t reflect.Type) bool {
+	kind := indirectType(t).Kind()
+	return kind == reflect.Struct || kind == reflect.Map
+}
+
+func (FormCodec) CanEncode(reflect.Type) bool {
+	return false
+}
+
+func (FormCodec) FieldTag() string {
+	return "schema"
+}
+
+// BinaryCodec is the application/octet-stream codec, for []byte bodies and responses.
+type BinaryCodec struct{}
+
+func (BinaryCodec) MediaTypes() []string {
+	return []string{"application/octet-stream"}
+}
+
+func (BinaryCodec) Decode(r *http.Request, v any, _ DecodeOptions) error {
+	target, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("could not convert bytes to %T. To read binary data from the request, use []byte as the body type", reflect.ValueOf(v).Elem().Interface())
+	}
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	*target = content
+
+	return nil
+}
+
+func (BinaryCodec) Encode(w http.ResponseWriter, _ *http.Request, v any) error {
+	content, ok := v.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot encode %T as application/octet-stream", v)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, err := w.Write(content)
+	return err
+}
+
+func (BinaryCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendJSONError(w, r, err)
+}
+
+func (BinaryCodec) CanDecode(t reflect.Type) bool {
+	return t == reflect.TypeFor[[]byte]()
+}
+
+func (BinaryCodec) CanEncode(t reflect.Type) bool {
+	return t == reflect.TypeFor[[]byte]()
+}
+
+// ignoreEOF ignores the error of empty bodies.
+func ignoreEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// indirectType returns the type pointed to by pointer types.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// problemMediaTypes are the problem details media types (RFC 9457) the errors can be negotiated to,
+// encoded by the codec of their base media type. They are not offered for the successful responses.
+var problemMediaTypes = map[string]string{
+	"application/problem+json": "application/json",
+	"application/problem+xml":  "application/xml",
+}
+
+// codecRegistry is the list of the codecs of a server, by order of preference.
+type codecRegistry []Codec
+
+// defaultCodecs are the codecs of the servers, and of the requests not handled by a Fuego route.
+var defaultCodecs = codecRegistry{JSONCodec{}, XMLCodec{}, YAMLCodec{}, MsgpackCodec{}, CBORCodec{}, HTMLCodec{}, TextCodec{}, FormCodec{}, BinaryCodec{}}
+
+// codecFieldTag returns the struct tag naming the fields in the format of the codec, see [CodecFieldTag].
+// The formats of the other codecs, like MessagePack or CBOR, are named by their json tags.
+func codecFieldTag(codec Codec) string {
+	if fieldTag, ok := codec.(CodecFieldTag); ok && fieldTag.FieldTag() != "" {
+		return fieldTag.FieldTag()
+	}
+	return "json"
+}
+
+// codecsFromRequest returns the codecs of the server handling the request.
+func codecsFromRequest(r *http.Request) codecRegistry {
+	if s := serverFromRequest(r); s != nil && s.codecs != nil {
+		return s.codecs
+	}
+	return defaultCodecs
+}
+
+// with returns a new registry where the codec takes precedence over the existing ones.
+func (codecs codecRegistry) with(codec Codec) codecRegistry {
+	return append(codecRegistry{codec}, codecs...)
+}
+
+// lookup returns the codec of the media type, or nil if none handles it.
+func (codecs codecRegistry) lookup(mediaType string) Codec {
+	mediaType = strings.ToLower(mediaType)
+	for _, codec := range codecs {
+		if slices.ContainsFunc(codec.MediaTypes(), func(m string) bool { return strings.EqualFold(m, mediaType) }) {
+			return codec
+		}
+	}
+	return nil
+}
+
+// decoder returns the codec decoding the media type into the type, or nil if none can.
+func (codecs codecRegistry) decoder(mediaType string, t reflect.Type) Codec {
+	codec := codecs.lookup(mediaType)
+	if codec == nil || !canDecode(codec, t) {
+		return nil
+	}
+	return codec
+}
+
+// encoder returns the codec encoding the type to the media type, or nil if none can.
+func (codecs codecRegistry) encoder(mediaType string, t reflect.Type) Codec {
+	codec := codecs.lookup(mediaType)
+	if codec == nil || !canEncode(codec, t) {
+		return nil
+	}
+	return codec
+}
+
+// encodableMediaTypes returns all the media types the type can be encoded to, by order of preference.
+func (codecs codecRegistry) encodableMediaTypes(t reflect.Type) []string {
+	mediaTypes := []string{}
+	for _, codec := range codecs {
+		if canEncode(codec, t) {
+			mediaTypes = appendMissing(mediaTypes, codec.MediaTypes()...)
+		}
+	}
+	return mediaTypes
+}
+
+// documentedRequestTypes returns the preferred media type of each codec able to decode the type.
+func (codecs codecRegistry) documentedRequestTypes(t reflect.Type) []string {
+	mediaTypes := []string{}
+	for _, codec := range codecs {
+		if canDecode(codec, t) && len(codec.MediaTypes()) > 0 {
+			mediaTypes = appendMissing(mediaTypes, codec.MediaTypes()[0])
+		}
+	}
+	return mediaTypes
+}
+
+// documentedResponseTypes returns the preferred media type of each codec able to encode the type.
+func (codecs codecRegistry) documentedResponseTypes(t reflect.Type) []string {
+	mediaTypes := []string{}
+	for _, codec := range codecs {
+		if canEncode(codec, t) && len(codec.MediaTypes()) > 0 {
+			mediaTypes = appendMissing(mediaTypes, codec.MediaTypes()[0])
+		}
+	}
+	return mediaTypes
+}
+
+func canDecode(codec Codec, t reflect.Type) bool {
+	support, ok := codec.(CodecSupport)
+	return !ok || t == nil || support.CanDecode(t)
+}
+
+func canEncode(codec Codec, t reflect.Type) bool {
+	support, ok := codec.(CodecSupport)
+	return !ok || t == nil || support.CanEncode(t)
+}
+
+// appendMissing appends the lowercased media types not already in the list.
+func appendMissing(mediaTypes []string, others ...string) []string {
+	for _, mediaType := range others {
+		mediaType = strings.ToLower(mediaType)
+		if !slices.Contains(mediaTypes, mediaType) {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	return mediaTypes
+}
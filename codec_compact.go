@@ -0,0 +1,119 @@
+package fuego
+
+import (
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/ugorji/go/codec"
+)
+
+var (
+	_ Codec = MsgpackCodec{}
+	_ Codec = CBORCodec{}
+)
+
+// MsgpackCodec is the MessagePack codec. Like JSON, the fields are named by their json tags.
+type MsgpackCodec struct{}
+
+// msgpackHandles are the MessagePack handles, the strict one at index 1 disallowing unknown fields.
+var msgpackHandles = [2]*codec.MsgpackHandle{newMsgpackHandle(false), newMsgpackHandle(true)}
+
+func newMsgpackHandle(disallowUnknownFields bool) *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.RawToString = true
+	configureCompactHandle(&h.BasicHandle, disallowUnknownFields)
+	return h
+}
+
+func (MsgpackCodec) MediaTypes() []string {
+	return []string{"application/msgpack", "application/vnd.msgpack", "application/x-msgpack"}
+}
+
+func (MsgpackCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	return ignoreEOF(codec.NewDecoder(r.Body, msgpackHandles[handleIndex(options)]).Decode(v))
+}
+
+func (MsgpackCodec) Encode(w http.ResponseWriter, _ *http.Request, v any) error {
+	return encodeCompact(w, msgpackHandles[0], "application/msgpack", v)
+}
+
+func (MsgpackCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	encodeCompactError(w, r, msgpackHandles[0], "application/msgpack", err)
+}
+
+// CBORCodec is the CBOR codec (RFC 8949). Like JSON, the fields are named by their json tags.
+type CBORCodec struct{}
+
+// cborHandles are the CBOR handles, the strict one at index 1 disallowing unknown fields.
+var cborHandles = [2]*codec.CborHandle{newCBORHandle(false), newCBORHandle(true)}
+
+func newCBORHandle(disallowUnknownFields bool) *codec.CborHandle {
+	h := &codec.CborHandle{TimeRFC3339: true}
+	configureCompactHandle(&h.BasicHandle, disallowUnknownFields)
+	return h
+}
+
+func (CBORCodec) MediaTypes() []string {
+	return []string{"application/cbor"}
+}
+
+func (CBORCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	return ignoreEOF(codec.NewDecoder(r.Body, cborHandles[handleIndex(options)]).Decode(v))
+}
+
+func (CBORCodec) Encode(w http.ResponseWriter, _ *http.Request, v any) error {
+	return encodeCompact(w, cborHandles[0], "application/cbor", v)
+}
+
+func (CBORCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	encodeCompactError(w, r, cborHandles[0], "application/cbor", err)
+}
+
+// configureCompactHandle names the fields by their json tags, decodes maps like JSON,
+// and errors on unknown fields if asked to.
+func configureCompactHandle(h *codec.BasicHandle, disallowUnknownFields bool) {
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	h.MapType = reflect.TypeFor[map[string]any]()
+	h.ErrorIfNoField = disallowUnknownFields
+}
+
+// handleIndex returns the index of the handle matching the decode options.
+func handleIndex(options DecodeOptions) int {
+	if options.DisallowUnknownFields {
+		return 1
+	}
+	return 0
+}
+
+// encodeCompact encodes v before writing it, so encoding errors can still be answered with a 500 status.
+func encodeCompact(w http.ResponseWriter, h codec.Handle, contentType string, v any) error {
+	var content []byte
+	err := codec.NewEncoderBytes(&content, h).Encode(v)
+	if err != nil {
+		slog.Error("Cannot serialize returned response to "+contentType, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	_, err = w.Write(content)
+	return err
+}
+
+// encodeCompactError encodes the problem details of the error (RFC 9457), with its status code.
+func encodeCompactError(w http.ResponseWriter, r *http.Request, h codec.Handle, contentType string, err error) {
+	problem := problemOf(r, err)
+
+	var content []byte
+	encodingErr := codec.NewEncoderBytes(&content, h).Encode(problem)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to "+contentType, "error", encodingErr)
+		w.WriteHeader(problem.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(content)
+}
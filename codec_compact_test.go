@@ -0,0 +1,91 @@
+package fuego
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestCompactCodecs(t *testing.T) {
+	s := NewServer(WithoutLogger(), WithContentNegotiation())
+
+	Post(s.RouterGroup(), "/echo", func(c *ContextWithBody[MyStruct]) (MyStruct, error) {
+		body, err := c.Body()
+		if err != nil {
+			return body, err
+		}
+		if body.B == "missing" {
+			return body, NotFoundError{Title: "Not Found", Detail: "nothing here", Err: http.ErrNoLocation}
+		}
+		return body, nil
+	})
+
+	handles := map[string]codec.Handle{
+		"application/msgpack": msgpackHandles[0],
+		"application/cbor":    cborHandles[0],
+	}
+
+	for mediaType, h := range handles {
+		encode := func(t *testing.T, v any) []byte {
+			var content []byte
+			require.NoError(t, codec.NewEncoderBytes(&content, h).Encode(v))
+			return content
+		}
+
+		send := func(body []byte) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+			r.Header.Set("Content-Type", mediaType)
+			r.Header.Set("Accept", mediaType)
+			s.ServeHTTP(w, r)
+			return w
+		}
+
+		t.Run(mediaType, func(t *testing.T) {
+			t.Run("round trips with the json field names", func(t *testing.T) {
+				w := send(encode(t, map[string]any{"b": "hello", "c": 3, "d": true}))
+
+				require.Equal(t, http.StatusOK, w.Code)
+				require.Equal(t, mediaType, w.Header().Get("Content-Type"))
+
+				var response map[string]any
+				require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), h).Decode(&response))
+				require.Equal(t, "hello", response["b"])
+				require.EqualValues(t, 3, response["c"])
+				require.Equal(t, true, response["d"])
+			})
+
+			t.Run("disallows unknown fields", func(t *testing.T) {
+				w := send(encode(t, map[string]any{"b": "hello", "unknown": 1}))
+
+				require.Equal(t, http.StatusBadRequest, w.Code)
+				require.Equal(t, mediaType, w.Header().Get("Content-Type"))
+			})
+
+			t.Run("encodes errors", func(t *testing.T) {
+				w := send(encode(t, map[string]any{"b": "missing"}))
+
+				require.Equal(t, http.StatusNotFound, w.Code)
+
+				var response map[string]any
+				require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), h).Decode(&response))
+				require.Equal(t, "nothing here", response["detail"])
+				require.NotContains(t, response, "Err")
+			})
+		})
+	}
+
+	t.Run("documents the formats", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		operation := s.OpenApiSpec.Paths.Find("/echo").Post
+		for mediaType := range handles {
+			require.Contains(t, operation.RequestBody.Value.Content, mediaType)
+			require.Contains(t, operation.Responses.Status(http.StatusOK).Value.Content, mediaType)
+		}
+	})
+}
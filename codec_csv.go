@@ -0,0 +1,356 @@
+package fuego
+
+import (
+	"encoding"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// csvFlushRows is the number of rows written between two flushes of a CSV response.
+const csvFlushRows = 500
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+var (
+	_ Codec         = CSVCodec{}
+	_ CodecSupport  = CSVCodec{}
+	_ CodecFieldTag = CSVCodec{}
+)
+
+// CSVCodec is the text/csv codec, for slices of structs. It is not enabled by default.
+// The first row holds the column names, taken from the `csv` struct tags, or from the json names.
+// Fields tagged `csv:"-"` are skipped. Numbers, booleans, strings and [encoding.TextMarshaler] are written as is,
+// other values as JSON. The text cells starting with =, +, -, @, a tab or a carriage return are prefixed
+// with a single quote, so spreadsheets do not evaluate them as formulas.
+// The rows are streamed, and flushed every few hundred rows. The controllers returning an iter.Seq[T]
+// or a channel of T can also be streamed as CSV, without collecting the rows in a slice.
+// If encoding a row fails once the first rows are sent, the connection is aborted,
+// so the client does not mistake the truncated content for the whole one.
+// For example:
+//
+//	app := fuego.NewServer(
+//		fuego.WithContentNegotiation(),
+//		fuego.WithCodec(fuego.CSVCodec{Delimiter: ';'}),
+//	)
+type CSVCodec struct {
+	Delimiter     rune // Field delimiter. Defaults to ','.
+	AllowFormulas bool // If true, the text cells that spreadsheets evaluate as formulas are written as is.
+}
+
+func (CSVCodec) MediaTypes() []string {
+	return []string{"text/csv"}
+}
+
+func (c CSVCodec) Decode(r *http.Request, v any, options DecodeOptions) error {
+	slice := reflect.ValueOf(v).Elem()
+	if !c.CanDecode(slice.Type()) {
+		return fmt.Errorf("cannot decode text/csv into %s", slice.Type())
+	}
+	rowType := slice.Type().Elem()
+	columns := csvColumns(indirectType(rowType))
+
+	reader := csv.NewReader(r.Body)
+	reader.Comma = c.delimiter()
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := make([]*csvColumn, len(header))
+	for i, name := range header {
+		for j := range columns {
+			if columns[j].name == strings.TrimSpace(name) {
+				fields[i] = &columns[j]
+			}
+		}
+		if fields[i] == nil && options.DisallowUnknownFields {
+			return fmt.Errorf("unknown column %q", name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		row := reflect.New(indirectType(rowType)).Elem()
+		for i, cell := range record {
+			if i >= len(fields) || fields[i] == nil || cell == "" {
+				continue
+			}
+			err = setCSVCell(row.FieldByIndex(fields[i].index), cell)
+			if err != nil {
+				line, _ := reader.FieldPos(i)
+				return fmt.Errorf("line %d, column %s: %w", line, fields[i].name, err)
+			}
+		}
+
+		if rowType.Kind() == reflect.Pointer {
+			row = row.Addr()
+		}
+		slice.Set(reflect.Append(slice, row))
+	}
+}
+
+func (c CSVCodec) Encode(w http.ResponseWriter, r *http.Request, v any) error {
+	rows := reflect.ValueOf(v)
+	if !c.CanEncode(rows.Type()) {
+		return fmt.Errorf("cannot encode %T as text/csv", v)
+	}
+	for rows.Kind() == reflect.Pointer {
+		rows = rows.Elem()
+	}
+
+	return c.encodeRows(w, r, rows.Type().Elem(), func(yield func(reflect.Value) bool) {
+		for i := range rows.Len() {
+			if !yield(rows.Index(i)) {
+				return
+			}
+		}
+	})
+}
+
+// encodeSequence streams the items of the sequence as CSV rows.
+func (c CSVCodec) encodeSequence(w http.ResponseWriter, r *http.Request, seq sequence) error {
+	rowType, _ := sequenceElem(seq.items.Type())
+
+	return c.encodeRows(w, r, rowType, func(yield func(reflect.Value) bool) {
+		seq.each(r, func(item any) bool {
+			row := reflect.ValueOf(item)
+			if !row.IsValid() {
+				row = reflect.Zero(rowType)
+			}
+			return yield(row)
+		})
+	})
+}
+
+// encodeRows writes the header and the rows.
+// Errors occurring once some content is written to the response abort the connection.
+func (c CSVCodec) encodeRows(w http.ResponseWriter, r *http.Request, rowType reflect.Type, rows func(yield func(reflect.Value) bool)) error {
+	columns := csvColumns(indirectType(rowType))
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	output := &csvOutput{w: w}
+	writer := csv.NewWriter(output)
+	writer.Comma = c.delimiter()
+	controller := http.NewResponseController(w)
+
+	record := make([]string, len(columns))
+	for i, column := range columns {
+		record[i] = column.name
+	}
+	err := writer.Write(record)
+
+	i := 0
+	rows(func(row reflect.Value) bool {
+		if err != nil {
+			return false
+		}
+
+		for row.Kind() == reflect.Pointer && !row.IsNil() {
+			row = row.Elem()
+		}
+
+		for j, column := range columns {
+			record[j] = ""
+			if row.Kind() == reflect.Struct {
+				record[j], err = c.cell(row.FieldByIndex(column.index))
+				if err != nil {
+					err = fmt.Errorf("row %d, column %s: %w", i, column.name, err)
+					return false
+				}
+			}
+		}
+
+		err = writer.Write(record)
+		i++
+		if err == nil && i%csvFlushRows == 0 {
+			writer.Flush()
+			err = writer.Error()
+			_ = controller.Flush()
+		}
+		return err == nil
+	})
+
+	if err == nil {
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err != nil && output.written {
+		slog.Error("Cannot encode the CSV response, aborting the connection", "path", r.URL.Path, "error", err)
+		panic(http.ErrAbortHandler)
+	}
+	return err
+}
+
+// cell formats the value of a CSV cell, escaping the formulas unless allowed.
+func (c CSVCodec) cell(v reflect.Value) (string, error) {
+	cell, err := csvCell(v)
+	if err != nil || c.AllowFormulas || indirectValue(v).Kind() != reflect.String && !isTextMarshaler(v) {
+		return cell, err
+	}
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		cell = "'" + cell
+	}
+	return cell, nil
+}
+
+// csvOutput records whether the CSV writer wrote to the response.
+type csvOutput struct {
+	w       io.Writer
+	written bool
+}
+
+func (o *csvOutput) Write(p []byte) (int, error) {
+	o.written = true
+	return o.w.Write(p)
+}
+
+func (CSVCodec) EncodeError(w http.ResponseWriter, r *http.Request, err error) {
+	SendJSONError(w, r, err)
+}
+
+// CanDecode reports whether the type is a slice of structs.
+func (CSVCodec) CanDecode(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && indirectType(t.Elem()).Kind() == reflect.Struct
+}
+
+// CanEncode reports whether the type is a slice or an array of structs.
+func (CSVCodec) CanEncode(t reflect.Type) bool {
+	t = indirectType(t)
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && indirectType(t.Elem()).Kind() == reflect.Struct
+}
+
+func (CSVCodec) FieldTag() string {
+	return "csv"
+}
+
+func (c CSVCodec) delimiter() rune {
+	if c.Delimiter == 0 {
+		return ','
+	}
+	return c.Delimiter
+}
+
+// csvColumn is a struct field written in a CSV column.
+type csvColumn struct {
+	index []int
+	name  string
+}
+
+// csvColumns returns the columns of the struct type, including the fields of embedded structs.
+func csvColumns(t reflect.Type) []csvColumn {
+	columns := []csvColumn{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("csv"), ",")
+		if name == "" {
+			name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+		}
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, embedded := range csvColumns(field.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				columns = append(columns, embedded)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, csvColumn{index: []int{i}, name: name})
+	}
+
+	return columns
+}
+
+// indirectValue returns the value pointed to by the non-nil pointers and interfaces.
+func indirectValue(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	return v
+}
+
+// isTextMarshaler reports whether the value is written as text by its MarshalText method.
+func isTextMarshaler(v reflect.Value) bool {
+	v = indirectValue(v)
+	return v.IsValid() && (v.Type().Implements(textMarshalerType) || v.CanAddr() && v.Addr().Type().Implements(textMarshalerType))
+}
+
+// csvCell formats the value of a CSV cell.
+func csvCell(v reflect.Value) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textMarshalerType) {
+		text, err := v.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	default:
+		content, err := json.Marshal(v.Interface())
+		return string(content), err
+	}
+}
+
+// setCSVCell parses the value of a CSV cell, written by [csvCell].
+func setCSVCell(v reflect.Value, cell string) error {
+	t := indirectType(v.Type())
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return setParamValue(v, []string{cell})
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct, reflect.Interface:
+		return json.Unmarshal([]byte(cell), v.Addr().Interface())
+	default:
+		return setParamValue(v, []string{cell})
+	}
+}
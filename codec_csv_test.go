@@ -0,0 +1,233 @@
+package fuego
+
+import (
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type csvRow struct {
+	Name     string     `json:"name"`
+	Quantity int        `csv:"qty"`
+	Tags     []string   `json:"tags"`
+	Expires  *time.Time `json:"expires,omitempty"`
+	Secret   string     `csv:"-"`
+}
+
+type csvCellRow struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+func TestCSVCodec(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithContentNegotiation(),
+		WithCodec(CSVCodec{Delimiter: ';'}),
+	)
+
+	expires := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []csvRow{
+		{Name: "apple; green", Quantity: 3, Tags: []string{"fruit"}, Expires: &expires, Secret: "s"},
+		{Name: "pear", Quantity: 1},
+	}
+
+	Get(s.RouterGroup(), "/rows", func(c ContextNoBody) ([]csvRow, error) {
+		return rows, nil
+	})
+
+	Post(s.RouterGroup(), "/rows", func(c *ContextWithBody[[]*csvRow]) ([]*csvRow, error) {
+		return c.Body()
+	})
+
+	Get(s.RouterGroup(), "/row", func(c ContextNoBody) (csvRow, error) {
+		return rows[0], nil
+	})
+
+	expected := "name;qty;tags;expires\n" +
+		"\"apple; green\";3;\"[\"\"fruit\"\"]\";2024-01-02T03:04:05Z\n" +
+		"pear;1;null;\n"
+
+	t.Run("encodes slices of structs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/rows", nil)
+		r.Header.Set("Accept", "text/csv")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, expected, w.Body.String())
+	})
+
+	t.Run("keeps JSON by default", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/rows", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("does not encode structs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/row", nil)
+		r.Header.Set("Accept", "text/csv")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("decodes slices of structs", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/rows", strings.NewReader(expected))
+		r.Header.Set("Content-Type", "text/csv")
+		r.Header.Set("Accept", "application/json")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[
+			{"name":"apple; green","Quantity":3,"tags":["fruit"],"expires":"2024-01-02T03:04:05Z","Secret":""},
+			{"name":"pear","Quantity":1,"tags":null,"Secret":""}
+		]`, w.Body.String())
+	})
+
+	t.Run("rejects unknown columns", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/rows", strings.NewReader("name;color\npear;green\n"))
+		r.Header.Set("Content-Type", "text/csv")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), "unknown column")
+	})
+
+	t.Run("documents text/csv for slices only", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		require.Contains(t, s.OpenApiSpec.Paths.Find("/rows").Get.Responses.Status(http.StatusOK).Value.Content, "text/csv")
+		require.Contains(t, s.OpenApiSpec.Paths.Find("/rows").Post.RequestBody.Value.Content, "text/csv")
+		require.NotContains(t, s.OpenApiSpec.Paths.Find("/row").Get.Responses.Status(http.StatusOK).Value.Content, "text/csv")
+	})
+
+	t.Run("documents text/csv for sequences", func(t *testing.T) {
+		s := NewServer(WithoutLogger(), WithContentNegotiation(), WithCodec(CSVCodec{}))
+		Get(s.RouterGroup(), "/seq", func(c ContextNoBody) (iter.Seq[csvRow], error) {
+			return nil, nil
+		})
+		s.OutputOpenAPISpec()
+
+		content := s.OpenApiSpec.Paths.Find("/seq").Get.Responses.Status(http.StatusOK).Value.Content
+		require.Contains(t, content, "application/x-ndjson")
+		require.Contains(t, content, "text/csv")
+	})
+}
+
+func TestCSVCodecFormulas(t *testing.T) {
+	rows := []csvCellRow{
+		{Name: "=HYPERLINK(\"http://example.com\")", Value: -3},
+		{Name: "+1", Value: "@SUM(A1)"},
+		{Name: "-", Value: "\tcmd"},
+		{Name: "safe", Value: "a=b"},
+	}
+
+	encode := func(codec CSVCodec) string {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		require.NoError(t, codec.Encode(w, r, rows))
+		return w.Body.String()
+	}
+
+	t.Run("escapes the formulas", func(t *testing.T) {
+		require.Equal(t, "name,value\n"+
+			"\"'=HYPERLINK(\"\"http://example.com\"\")\",-3\n"+
+			"'+1,'@SUM(A1)\n"+
+			"'-,'\tcmd\n"+
+			"safe,a=b\n", encode(CSVCodec{}))
+	})
+
+	t.Run("allows the formulas", func(t *testing.T) {
+		require.Equal(t, "name,value\n"+
+			"\"=HYPERLINK(\"\"http://example.com\"\")\",-3\n"+
+			"+1,@SUM(A1)\n"+
+			"-,\"\tcmd\"\n"+
+			"safe,a=b\n", encode(CSVCodec{AllowFormulas: true}))
+	})
+}
+
+func TestCSVCodecSequence(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithContentNegotiation(),
+		WithCodec(CSVCodec{}),
+	)
+
+	// rows yields n rows, followed by a row that cannot be encoded if failing is true.
+	rows := func(n int, failing bool) iter.Seq[csvCellRow] {
+		return func(yield func(csvCellRow) bool) {
+			for i := range n {
+				if !yield(csvCellRow{Name: "row", Value: i}) {
+					return
+				}
+			}
+			if failing {
+				yield(csvCellRow{Name: "invalid", Value: func() {}})
+			}
+		}
+	}
+
+	Get(s.RouterGroup(), "/rows", func(c ContextNoBody) (iter.Seq[csvCellRow], error) {
+		return rows(2, false), nil
+	})
+
+	Get(s.RouterGroup(), "/early-failure", func(c ContextNoBody) (iter.Seq[csvCellRow], error) {
+		return rows(2, true), nil
+	})
+
+	Get(s.RouterGroup(), "/late-failure", func(c ContextNoBody) (iter.Seq[csvCellRow], error) {
+		return rows(2*csvFlushRows, true), nil
+	})
+
+	t.Run("streams iter.Seq as CSV", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/rows", nil)
+		r.Header.Set("Accept", "text/csv")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "name,value\nrow,0\nrow,1\n", w.Body.String())
+	})
+
+	t.Run("sends the errors occurring before the first rows are sent", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/early-failure", nil)
+		r.Header.Set("Accept", "text/csv")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.NotContains(t, w.Body.String(), "row,0")
+	})
+
+	t.Run("aborts the connection on errors once rows are sent", func(t *testing.T) {
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		r, err := http.NewRequest(http.MethodGet, server.URL+"/late-failure", nil)
+		require.NoError(t, err)
+		r.Header.Set("Accept", "text/csv")
+		res, err := http.DefaultClient.Do(r)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		require.Error(t, err, "the truncated content is not mistaken for the whole one")
+		require.NotContains(t, string(body), "Internal Server Error")
+	})
+}
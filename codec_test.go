@@ -0,0 +1,167 @@
+package fuego
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// keyValueCodec is a test codec for text/x-key-value bodies, made of "key=value" lines.
+type keyValueCodec struct{}
+
+func (keyValueCodec) MediaTypes() []string { return []string{"text/x-key-value"} }
+
+func (keyValueCodec) Decode(r *http.Request, v any, _ DecodeOptions) error {
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	values := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		key, value, _ := strings.Cut(line, "=")
+		values[key] = value
+	}
+	*v.(*map[string]string) = values
+	return nil
+}
+
+func (keyValueCodec) Encode(w http.ResponseWriter, _ *http.Request, v any) error {
+	w.Header().Set("Content-Type", "text/x-key-value")
+	for key, value := range v.(map[string]string) {
+		_, _ = w.Write([]byte(key + "=" + value + "\n"))
+	}
+	return nil
+}
+
+func (keyValueCodec) EncodeError(w http.ResponseWriter, _ *http.Request, err error) {
+	w.Header().Set("Content-Type", "text/x-key-value")
+	var errorWithStatus ErrorWithStatus
+	if errors.As(err, &errorWithStatus) {
+		w.WriteHeader(errorWithStatus.StatusCode())
+	}
+	_, _ = w.Write([]byte("error=" + err.Error() + "\n"))
+}
+
+func (keyValueCodec) CanDecode(t reflect.Type) bool { return t == reflect.TypeFor[map[string]string]() }
+
+func (keyValueCodec) CanEncode(t reflect.Type) bool {
+	return t == reflect.TypeFor[map[string]string]() || t == errorType
+}
+
+// labelCodec is a test codec for JSON bodies, naming the fields by their label tags.
+type labelCodec struct {
+	JSONCodec
+}
+
+func (labelCodec) MediaTypes() []string { return []string{"application/x-labels+json"} }
+
+func (labelCodec) FieldTag() string { return "label" }
+
+type labeledStruct struct {
+	Name string `json:"name" label:"display_name" validate:"required"`
+}
+
+func TestCodecFieldTag(t *testing.T) {
+	s := NewServer(WithoutLogger(), WithCodec(labelCodec{}))
+
+	Post(s.RouterGroup(), "/labeled", func(c *ContextWithBody[labeledStruct]) (labeledStruct, error) {
+		return c.Body()
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/labeled", strings.NewReader(`{}`))
+	r.Header.Set("Content-Type", "application/x-labels+json")
+	s.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	var problem HTTPError
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	require.Len(t, problem.Errors, 1)
+	require.Equal(t, "display_name", problem.Errors[0].Name)
+}
+
+func TestCodecs(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithContentNegotiation(),
+		WithCodec(keyValueCodec{}),
+	)
+
+	Post(s.RouterGroup(), "/labels", func(c *ContextWithBody[map[string]string]) (map[string]string, error) {
+		return c.Body()
+	})
+
+	Post(s.RouterGroup(), "/struct", func(c *ContextWithBody[MyStruct]) (MyStruct, error) {
+		return c.Body()
+	})
+
+	send := func(path, contentType, accept, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		r.Header.Set("Accept", accept)
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("decodes and encodes with a registered codec", func(t *testing.T) {
+		w := send("/labels", "text/x-key-value", "text/x-key-value", "team=core\n")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/x-key-value", w.Header().Get("Content-Type"))
+		require.Equal(t, "team=core\n", w.Body.String())
+	})
+
+	t.Run("keeps the default codecs", func(t *testing.T) {
+		w := send("/labels", "application/json", "application/x-yaml", `{"team":"core"}`)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/x-yaml", w.Header().Get("Content-Type"))
+		require.Equal(t, "team: core\n", w.Body.String())
+	})
+
+	t.Run("does not use a codec for unsupported types", func(t *testing.T) {
+		w := send("/struct", "text/x-key-value", "application/json", "b=c\n")
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+		w = send("/struct", "application/json", "text/x-key-value", `{"b":"c"}`)
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("rejects text bodies for structs", func(t *testing.T) {
+		w := send("/struct", "text/plain", "application/json", "hello")
+
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("rejects malformed content types", func(t *testing.T) {
+		w := send("/struct", "application json", "application/json", `{"b":"c"}`)
+
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		require.Contains(t, w.Body.String(), "invalid Content-Type header")
+	})
+
+	t.Run("documents the content types of the codecs", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		labels := s.OpenApiSpec.Paths.Find("/labels").Post
+		require.Contains(t, labels.RequestBody.Value.Content, "text/x-key-value")
+		require.Contains(t, labels.RequestBody.Value.Content, "application/json")
+		require.NotContains(t, labels.RequestBody.Value.Content, "application/xml")
+		require.Contains(t, labels.Responses.Status(http.StatusOK).Value.Content, "text/x-key-value")
+
+		structs := s.OpenApiSpec.Paths.Find("/struct").Post
+		require.NotContains(t, structs.RequestBody.Value.Content, "text/x-key-value")
+		require.Contains(t, structs.RequestBody.Value.Content, "application/x-www-form-urlencoded")
+		require.Equal(t, []string{"application/cbor", "application/json", "application/msgpack", "application/x-yaml", "application/xml"}, slices.Sorted(maps.Keys(structs.Responses.Status(http.StatusOK).Value.Content)))
+	})
+}
@@ -0,0 +1,127 @@
+package fuego
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CorsConfig is the CORS configuration of [WithCors].
+// The methods allowed by the preflight requests are the ones of the routes registered for the requested path.
+type CorsConfig struct {
+	AllowedOrigins   []string      // Origins allowed to send cross-origin requests, like "https://example.com". "*" allows all origins.
+	AllowedHeaders   []string      // Request headers allowed besides the CORS-safelisted ones, like "Authorization". "*" allows all headers.
+	ExposedHeaders   []string      // Response headers readable by the cross-origin requests, besides the CORS-safelisted ones.
+	AllowCredentials bool          // If true, the cross-origin requests can send cookies and credentials.
+	MaxAge           time.Duration // Duration the preflight responses can be cached by the browsers. Not sent if zero.
+}
+
+// corsSafelistedHeaders are the request headers always allowed in cross-origin requests.
+var corsSafelistedHeaders = []string{"Accept", "Accept-Language", "Content-Language", "Content-Type"}
+
+// handleCors answers the CORS preflight requests, with the methods of the routes registered for the path,
+// and adds the CORS headers to the cross-origin requests of allowed origins.
+// Preflight requests on paths with an OPTIONS route are answered by the route, with the CORS headers set.
+// Rejected preflight requests are answered without CORS headers, so the browser blocks the request.
+func (s *Server) handleCors(next http.Handler) http.Handler {
+	config := s.corsConfig
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		headers := w.Header()
+		addVary(headers, "Origin")
+
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		if r.Method != http.MethodOptions || requestedMethod == "" {
+			if config.originAllowed(origin) {
+				config.setOriginHeaders(headers, origin)
+				if len(config.ExposedHeaders) > 0 {
+					headers.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		addVary(headers, "Access-Control-Request-Method")
+		addVary(headers, "Access-Control-Request-Headers")
+
+		methods := s.allowedMethods(r.URL.Path)
+		if slices.Contains(methods, http.MethodOptions) {
+			if config.originAllowed(origin) {
+				config.setOriginHeaders(headers, origin)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestedHeaders := corsHeaderList(r.Header.Get("Access-Control-Request-Headers"))
+		if !config.originAllowed(origin) || !slices.Contains(methods, strings.ToUpper(requestedMethod)) || !config.headersAllowed(requestedHeaders) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		allow := strings.Join(append(methods, http.MethodOptions), ", ")
+		headers.Set("Allow", allow)
+		config.setOriginHeaders(headers, origin)
+		headers.Set("Access-Control-Allow-Methods", allow)
+		if len(requestedHeaders) > 0 {
+			headers.Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+		}
+		if config.MaxAge > 0 {
+			headers.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// originAllowed reports whether the origin is one of the allowed origins.
+func (config CorsConfig) originAllowed(origin string) bool {
+	return slices.ContainsFunc(config.AllowedOrigins, func(allowed string) bool {
+		return allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin)
+	})
+}
+
+// headersAllowed reports whether the requested headers are all safelisted or allowed.
+func (config CorsConfig) headersAllowed(requested []string) bool {
+	if slices.Contains(config.AllowedHeaders, "*") {
+		return true
+	}
+	for _, header := range requested {
+		isHeader := func(h string) bool { return strings.EqualFold(h, header) }
+		if !slices.ContainsFunc(corsSafelistedHeaders, isHeader) && !slices.ContainsFunc(config.AllowedHeaders, isHeader) {
+			return false
+		}
+	}
+	return true
+}
+
+// setOriginHeaders allows the origin to read the response.
+// The origin is sent back instead of "*" when the credentials are allowed, as browsers require.
+func (config CorsConfig) setOriginHeaders(headers http.Header, origin string) {
+	if slices.Contains(config.AllowedOrigins, "*") && !config.AllowCredentials {
+		headers.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		headers.Set("Access-Control-Allow-Origin", origin)
+	}
+	if config.AllowCredentials {
+		headers.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// corsHeaderList splits the comma-separated header names of the Access-Control-Request-Headers header.
+func corsHeaderList(value string) []string {
+	names := []string{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
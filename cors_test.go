@@ -0,0 +1,96 @@
+package fuego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCors(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithCors(CorsConfig{
+			AllowedOrigins:   []string{"https://example.com"},
+			AllowedHeaders:   []string{"Authorization"},
+			ExposedHeaders:   []string{"X-Total"},
+			AllowCredentials: true,
+			MaxAge:           time.Hour,
+		}),
+	)
+
+	Put(s.RouterGroup(), "/items/:id", func(ContextNoBody) (string, error) {
+		return "updated", nil
+	})
+	Delete(s.RouterGroup(), "/items/:id", func(ContextNoBody) (string, error) {
+		return "deleted", nil
+	})
+	Get(s.RouterGroup(), "/custom", func(ContextNoBody) (string, error) {
+		return "custom", nil
+	})
+	Register(s.RouterGroup(), Route{Method: http.MethodOptions, Path: "/custom"}, FuegoHandler(s, func(ContextNoBody) (string, error) {
+		return "custom options", nil
+	}))
+
+	preflight := func(path, origin, method, headers string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodOptions, path, nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			r.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("allows the methods of the registered routes", func(t *testing.T) {
+		w := preflight("/items/42", "https://example.com", http.MethodDelete, "authorization, content-type")
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "DELETE, PUT, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "authorization, content-type", w.Header().Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "3600", w.Header().Get("Access-Control-Max-Age"))
+		require.Equal(t, "DELETE, PUT, OPTIONS", w.Header().Get("Allow"))
+	})
+
+	t.Run("rejects the methods without route", func(t *testing.T) {
+		w := preflight("/items/42", "https://example.com", http.MethodGet, "")
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("rejects the other origins and headers", func(t *testing.T) {
+		w := preflight("/items/42", "https://evil.example", http.MethodPut, "")
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = preflight("/items/42", "https://example.com", http.MethodPut, "X-Secret")
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("lets the OPTIONS routes answer the preflight requests", func(t *testing.T) {
+		w := preflight("/custom", "https://example.com", http.MethodGet, "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "custom options")
+		require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("adds the CORS headers to the cross-origin requests", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/items/42", nil)
+		r.Header.Set("Origin", "https://example.com")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "https://example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "X-Total", w.Header().Get("Access-Control-Expose-Headers"))
+		require.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+}
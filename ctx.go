@@ -2,13 +2,15 @@ package fuego
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
-	"io"
 	"io/fs"
 	"log/slog"
+	"mime"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -73,6 +75,7 @@ type ctx[B any] interface {
 	Cookie(name string) (*http.Cookie, error) // Get request cookie
 	SetCookie(cookie http.Cookie)             // Sets response cookie
 	Header(key string) string                 // Get request header
+	LastEventID() string                      // Get the Last-Event-ID request header, sent by Server-Sent Events clients when reconnecting. See [EventStream]
 	SetHeader(key, value string)              // Sets response header
 
 	Context() context.Context
@@ -102,6 +105,9 @@ func NewContext[B any](w http.ResponseWriter, r *http.Request, options readOptio
 			readOptions: readOptions{
 				DisallowUnknownFields: options.DisallowUnknownFields,
 				MaxBodySize:           options.MaxBodySize,
+				MaxFileSize:           options.MaxFileSize,
+				Validator:             options.Validator,
+				Locale:                mainLocale(r),
 			},
 		},
 	}
@@ -127,6 +133,7 @@ type ContextNoBody struct {
 	templates *template.Template
 	ginCtx    *gin.Context
 
+	codecs      codecRegistry // Codecs of the server, the default ones if nil.
 	readOptions readOptions
 }
 
@@ -158,7 +165,11 @@ func (c ContextNoBody) SetStatus(code int) {
 type readOptions struct {
 	DisallowUnknownFields bool
 	MaxBodySize           int64
+	MaxFileSize           int64 // Maximum size of each uploaded file in multipart/form-data bodies. No limit other than MaxBodySize if 0.
 	LogBody               bool
+	Validator             *structValidator // Validator of the server, the default one if nil.
+	Locale                string           // Locale of the validation messages, the main locale of the request.
+	FieldTag              string           // Struct tag naming the fields in the decoded format, for the validation errors. Defaults to json.
 }
 
 var (
@@ -209,6 +220,12 @@ func (c ContextNoBody) Header(key string) string {
 	return c.Request().Header.Get(key)
 }
 
+// LastEventID returns the ID of the last event received by a reconnecting Server-Sent Events client.
+// It is empty on the first connection. See [EventStream].
+func (c ContextNoBody) LastEventID() string {
+	return c.Request().Header.Get("Last-Event-ID")
+}
+
 // Sets response header
 func (c ContextNoBody) SetHeader(key, value string) {
 	c.Response().Header().Set(key, value)
@@ -348,7 +365,12 @@ func (c ContextNoBody) MainLang() string {
 }
 
 func (c ContextNoBody) MainLocale() string {
-	return strings.Split(c.Req.Header.Get("Accept-Language"), ",")[0]
+	return mainLocale(c.Req)
+}
+
+// mainLocale returns the first locale of the Accept-Language header of the request.
+func mainLocale(r *http.Request) string {
+	return strings.Split(r.Header.Get("Accept-Language"), ",")[0]
 }
 
 // Request returns the HTTP request.
@@ -375,6 +397,7 @@ func (c *ContextWithBody[B]) MustBody() B {
 // It caches the result, so it can be called multiple times.
 // The reason the body is cached is that it is impossible to read an HTTP request body multiple times, not because of performance.
 // For decoding, it uses the Content-Type header. If it is not set, defaults to application/json.
+// It returns an [UnsupportedMediaTypeError] if no codec of the server can decode the Content-Type into B.
 func (c *ContextWithBody[B]) Body() (B, error) {
 	if c.body != nil {
 		return *c.body, nil
@@ -393,36 +416,32 @@ func body[B any](c ContextNoBody) (B, error) {
 
 	timeDeserialize := time.Now()
 
-	var body B
-	var err error
-	switch c.Req.Header.Get("Content-Type") {
-	case "text/plain":
-		s, errReadingString := readString[string](c.Req.Context(), c.Req.Body, c.readOptions)
-		body = any(s).(B)
-		err = errReadingString
-	case "application/x-www-form-urlencoded", "multipart/form-data":
-		body, err = readURLEncoded[B](c.Req, c.readOptions)
-	case "application/xml":
-		body, err = readXML[B](c.Req.Context(), c.Req.Body, c.readOptions)
-	case "application/x-yaml", "text/yaml; charset=utf-8", "application/yaml": // https://www.rfc-editor.org/rfc/rfc9512.html
-		body, err = readYAML[B](c.Req.Context(), c.Req.Body, c.readOptions)
-	case "application/octet-stream":
-		// Read c.Req Body to bytes
-		bytes, err := io.ReadAll(c.Req.Body)
-		if err != nil {
-			return body, err
+	contentType := c.Req.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil && contentType != "" && !errors.Is(err, mime.ErrInvalidMediaParameter) {
+		return *new(B), UnsupportedMediaTypeError{
+			Detail: "invalid Content-Type header " + contentType,
+			Err:    err,
 		}
-		respBytes, ok := any(bytes).(B)
-		if !ok {
-			return body, fmt.Errorf("could not convert bytes to %T. To read binary data from the request, use []byte as the body type", body)
+	}
+
+	codecs := c.codecs
+	if codecs == nil {
+		codecs = defaultCodecs
+	}
+	var codec Codec = JSONCodec{}
+	if mediaType != "" {
+		codec = codecs.lookup(mediaType)
+	}
+	if codec == nil {
+		return *new(B), UnsupportedMediaTypeError{
+			Detail: "cannot decode " + mediaType + " request body, supported media types are " + strings.Join(codecs.documentedRequestTypes(reflect.TypeFor[B]()), ", "),
+			Err:    errors.New("no codec for " + mediaType),
 		}
-		body = respBytes
-	case "application/json":
-		fallthrough
-	default:
-		body, err = readJSON[B](c.Req.Context(), c.Req.Body, c.readOptions)
 	}
 
+	body, err := decodeBody[B](c.Req, codec, mediaType, c.readOptions)
+
 	c.Res.Header().Add("Server-Timing", Timing{"deserialize", time.Since(timeDeserialize), "controller > deserialize"}.String())
 
 	return body, err
@@ -0,0 +1,413 @@
+package fuego
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ContextWithParams is the same as fuego.ContextNoBody, but has typed parameters.
+// The Params type parameter is a struct whose fields are tagged with their location
+// (query, path, header or cookie) and name. For example:
+//
+//	type MyParams struct {
+//		ID      int      `path:"id"`
+//		Limit   int      `query:"limit" default:"10" validate:"max=100"`
+//		Tags    []string `query:"tags"`
+//		Tenant  string   `header:"X-Tenant" validate:"required"`
+//		Session string   `cookie:"session"`
+//	}
+//
+// The parameters are documented in the OpenAPI spec with their type.
+// Please do not use a pointer as a type parameter.
+type ContextWithParams[Params any] struct {
+	params *Params // Cache the decoded params
+	ContextNoBody
+}
+
+// ContextFull is the same as fuego.ContextWithBody, but also has typed parameters.
+// See [ContextWithParams] for the Params struct tags.
+type ContextFull[Body, Params any] struct {
+	params *Params // Cache the decoded params
+	ContextWithBody[Body]
+}
+
+var (
+	_ ctx[any]    = &ContextWithParams[any]{}        // Check that ContextWithParams[any] implements Ctx.
+	_ ctx[string] = &ContextFull[string, struct{}]{} // Check that ContextFull[string, struct{}] implements Ctx.
+)
+
+// contextInitializer is implemented by the context types that are initialized from a [ContextNoBody] by [initContext].
+type contextInitializer interface {
+	initContext(baseContext ContextNoBody)
+}
+
+// paramsDescriber is implemented by the context types with typed parameters.
+// It must work on nil receivers, as it is used when registering the routes.
+type paramsDescriber interface {
+	zeroParams() any
+}
+
+func (c *ContextWithParams[Params]) initContext(baseContext ContextNoBody) {
+	c.ContextNoBody = baseContext
+}
+
+func (c *ContextWithParams[Params]) zeroParams() any {
+	return *new(Params)
+}
+
+// Params returns the typed parameters of the request.
+// Default values are applied and the parameters are validated.
+// It caches the result, so it can be called multiple times.
+func (c *ContextWithParams[Params]) Params() (Params, error) {
+	if c.params != nil {
+		return *c.params, nil
+	}
+
+	params, err := readParams[Params](c.ContextNoBody)
+	if err == nil {
+		c.params = &params
+	}
+	return params, err
+}
+
+// MustParams works like Params, but panics if there is an error.
+func (c *ContextWithParams[Params]) MustParams() Params {
+	params, err := c.Params()
+	if err != nil {
+		panic(err)
+	}
+	return params
+}
+
+func (c *ContextFull[Body, Params]) initContext(baseContext ContextNoBody) {
+	c.ContextNoBody = baseContext
+}
+
+func (c *ContextFull[Body, Params]) zeroParams() any {
+	return *new(Params)
+}
+
+// Params returns the typed parameters of the request.
+// Default values are applied and the parameters are validated.
+// It caches the result, so it can be called multiple times.
+func (c *ContextFull[Body, Params]) Params() (Params, error) {
+	if c.params != nil {
+		return *c.params, nil
+	}
+
+	params, err := readParams[Params](c.ContextNoBody)
+	if err == nil {
+		c.params = &params
+	}
+	return params, err
+}
+
+// MustParams works like Params, but panics if there is an error.
+func (c *ContextFull[Body, Params]) MustParams() Params {
+	params, err := c.Params()
+	if err != nil {
+		panic(err)
+	}
+	return params
+}
+
+// paramsOf returns the zero value of the typed parameters of the context type, or nil if it has none.
+func paramsOf[Contexted any]() any {
+	var c Contexted
+	if describer, ok := any(c).(paramsDescriber); ok {
+		return describer.zeroParams()
+	}
+	return nil
+}
+
+// paramField is a struct field bound to a request parameter.
+type paramField struct {
+	field reflect.StructField
+	index []int
+	in    ParamType
+	name  string
+}
+
+// pathParamType is the location of path parameters.
+// It is not a [ParamType] usable with [Route.Param] as path parameters are documented from the route path.
+const pathParamType ParamType = openapi3.ParameterInPath
+
+var paramTags = [...]ParamType{QueryParamType, pathParamType, HeaderParamType, CookieParamType}
+
+// paramFields returns the fields of the struct type that are tagged as parameters, including the ones of embedded structs.
+func paramFields(t reflect.Type) []paramField {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []paramField{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, embedded := range paramFields(field.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+
+		for _, in := range paramTags {
+			name, ok := field.Tag.Lookup(string(in))
+			if !ok || name == "" || name == "-" {
+				continue
+			}
+
+			fields = append(fields, paramField{field: field, index: []int{i}, in: in, name: name})
+			break
+		}
+	}
+
+	return fields
+}
+
+// readParams decodes the request parameters into the Params struct, then validates it.
+func readParams[Params any](c ContextNoBody) (Params, error) {
+	var params Params
+
+	paramsValue := reflect.ValueOf(&params).Elem()
+	if paramsValue.Kind() != reflect.Struct {
+		return params, fmt.Errorf("params must be a struct, got %T", params)
+	}
+
+	fields := paramFields(paramsValue.Type())
+
+	var errorItems []ErrorItem
+	for _, field := range fields {
+		values := paramValues(c, field)
+		if len(values) == 0 {
+			var ok bool
+			values, ok = defaultParamValues(field.field)
+			if !ok {
+				continue
+			}
+		}
+
+		err := setParamValue(paramsValue.FieldByIndex(field.index), values)
+		if err != nil {
+			errorItems = append(errorItems, ErrorItem{
+				Name:   field.name,
+				In:     string(field.in),
+				Reason: fmt.Sprintf("%s parameter %s=%s is invalid: %s", field.in, field.name, strings.Join(values, ","), err),
+				More: map[string]any{
+					"in":    string(field.in),
+					"value": strings.Join(values, ","),
+				},
+			})
+		}
+	}
+
+	if len(errorItems) > 0 {
+		reasons := make([]string, 0, len(errorItems))
+		for _, item := range errorItems {
+			reasons = append(reasons, item.Reason)
+		}
+
+		return params, BadRequestError{
+			Title:  "Invalid Parameters",
+			Detail: strings.Join(reasons, ", "),
+			Err:    errors.New("cannot decode request parameters: " + strings.Join(reasons, ", ")),
+			Errors: errorItems,
+		}
+	}
+
+	err := validate(params, c.readOptions)
+	if err != nil {
+		var validationError HTTPError
+		if !errors.As(err, &validationError) {
+			return params, err
+		}
+
+		// Report the errors with the parameters names and locations, rather than the struct fields
+		for i, item := range validationError.Errors {
+			structField, _ := item.More["field"].(string)
+			for _, field := range fields {
+				if field.field.Name == structField {
+					validationError.Errors[i].Name = field.name
+					validationError.Errors[i].In = string(field.in)
+					validationError.Errors[i].Pointer = ""
+					validationError.Errors[i].More["in"] = string(field.in)
+					break
+				}
+			}
+		}
+
+		return params, BadRequestError(validationError)
+	}
+
+	return params, nil
+}
+
+// paramValues returns the raw values of the parameter in the request.
+func paramValues(c ContextNoBody, field paramField) []string {
+	switch field.in {
+	case QueryParamType:
+		return c.Req.URL.Query()[field.name]
+	case pathParamType:
+		if value := c.PathParam(field.name); value != "" {
+			return []string{value}
+		}
+	case HeaderParamType:
+		return c.Req.Header.Values(field.name)
+	case CookieParamType:
+		cookie, err := c.Req.Cookie(field.name)
+		if err == nil {
+			return []string{cookie.Value}
+		}
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// defaultParamValues returns the values of the default tag of the field, if any.
+// The default of a slice field is a comma separated list (ex: default:"a,b"), the other defaults are a single value.
+func defaultParamValues(field reflect.StructField) ([]string, bool) {
+	defaultValue, ok := field.Tag.Lookup("default")
+	if !ok {
+		return nil, false
+	}
+
+	t := indirectType(field.Type)
+	if t.Kind() == reflect.Slice && !reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return strings.Split(defaultValue, ","), true
+	}
+	return []string{defaultValue}, true
+}
+
+// setParamValue sets the raw values to the field, converting them to the field type.
+func setParamValue(v reflect.Value, values []string) error {
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(values[0]))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setParamValue(v.Elem(), values)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i, value := range values {
+			err := setParamValue(slice.Index(i), []string{value})
+			if err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.String:
+		v.SetString(values[0])
+	case reflect.Bool:
+		b, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return errors.New("should be a boolean")
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(values[0], 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("should be an integer")
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(values[0], 10, v.Type().Bits())
+		if err != nil {
+			return errors.New("should be a positive integer")
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(values[0], v.Type().Bits())
+		if err != nil {
+			return errors.New("should be a number")
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported parameter type %s", v.Type())
+	}
+
+	return nil
+}
+
+// parametersFromStruct generates the OpenAPI parameters from the tagged fields of the params struct.
+// See [ContextWithParams] for the struct tags. The constraints of the validate tags are documented in the schemas.
+func (s *Server) parametersFromStruct(t reflect.Type) openapi3.Parameters {
+	parameters := openapi3.Parameters{}
+	for _, field := range paramFields(t) {
+		parameter := &openapi3.Parameter{
+			In:          string(field.in),
+			Name:        field.name,
+			Description: field.field.Tag.Get("description"),
+			Schema:      paramSchema(field.field.Type).NewRef(),
+		}
+
+		validateTag, hasValidateTag := field.field.Tag.Lookup("validate")
+		validateTags := strings.Split(validateTag, ",")
+		if field.in == pathParamType || slices.Contains(validateTags, "required") {
+			parameter.Required = true
+		}
+		if hasValidateTag {
+			s.documentValidateTags(field.field.Type, parameter.Schema.Value, validateTags)
+		}
+
+		if example, ok := field.field.Tag.Lookup("example"); ok {
+			parameter.Example = example
+		}
+
+		if defaultValue, ok := field.field.Tag.Lookup("default"); ok {
+			parameter.Schema.Value.Default = defaultValue
+		}
+
+		if field.field.Type.Kind() == reflect.Slice {
+			explode := true
+			parameter.Explode = &explode
+		}
+
+		parameters = append(parameters, &openapi3.ParameterRef{Value: parameter})
+	}
+
+	return parameters
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// paramSchema returns the OpenAPI schema of a parameter of the given type.
+func paramSchema(t reflect.Type) *openapi3.Schema {
+	if t == timeType {
+		return openapi3.NewDateTimeSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return paramSchema(t.Elem())
+	case reflect.Slice, reflect.Array:
+		return openapi3.NewArraySchema().WithItems(paramSchema(t.Elem()))
+	case reflect.Bool:
+		return openapi3.NewBoolSchema()
+	case reflect.Int64, reflect.Uint64:
+		return openapi3.NewInt64Schema()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return openapi3.NewIntegerSchema()
+	case reflect.Float32, reflect.Float64:
+		return openapi3.NewFloat64Schema()
+	default:
+		return openapi3.NewStringSchema()
+	}
+}
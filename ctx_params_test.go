@@ -0,0 +1,188 @@
+package fuego
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+)
+
+type paginationParams struct {
+	Limit int `query:"limit" default:"10" validate:"min=1,max=100" description:"Number of items"`
+}
+
+type listParams struct {
+	paginationParams
+	ID      int      `path:"id"`
+	Tags    []string `query:"tags" validate:"max=5,dive,alpha"`
+	Tenant  string   `header:"X-Tenant" validate:"required"`
+	Session string   `cookie:"session"`
+	Debug   *bool    `query:"debug"`
+	Ignored string
+}
+
+func TestContextWithParams(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Get(s.RouterGroup(), "/items/:id", func(c *ContextWithParams[listParams]) (listParams, error) {
+		return c.Params()
+	})
+
+	Post(s.RouterGroup(), "/items/:id", func(c *ContextFull[MyStruct, listParams]) (MyStruct, error) {
+		params, err := c.Params()
+		if err != nil {
+			return MyStruct{}, err
+		}
+
+		body, err := c.Body()
+		if err != nil {
+			return MyStruct{}, err
+		}
+		body.B = params.Tenant
+		body.C = params.ID
+		return body, nil
+	})
+
+	t.Run("decodes all locations", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/items/42?tags=a&tags=b&debug=true", nil)
+		r.Header.Set("X-Tenant", "acme")
+		r.AddCookie(&http.Cookie{Name: "session", Value: "s3cr3t"})
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var params listParams
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &params))
+		require.Equal(t, 42, params.ID)
+		require.Equal(t, 10, params.Limit)
+		require.Equal(t, []string{"a", "b"}, params.Tags)
+		require.Equal(t, "acme", params.Tenant)
+		require.Equal(t, "s3cr3t", params.Session)
+		require.NotNil(t, params.Debug)
+		require.True(t, *params.Debug)
+	})
+
+	t.Run("reports invalid parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/items/abc?limit=ten", nil)
+		r.Header.Set("X-Tenant", "acme")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var httpError HTTPError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &httpError))
+		require.Len(t, httpError.Errors, 2)
+		require.Equal(t, "limit", httpError.Errors[0].Name)
+		require.Equal(t, "query", httpError.Errors[0].More["in"])
+		require.Equal(t, "id", httpError.Errors[1].Name)
+		require.Equal(t, "path", httpError.Errors[1].More["in"])
+		require.Equal(t, "path", httpError.Errors[1].In)
+	})
+
+	t.Run("validates parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/items/1?limit=1000", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var httpError HTTPError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &httpError))
+		require.Len(t, httpError.Errors, 2)
+		require.Equal(t, "limit", httpError.Errors[0].Name)
+		require.Equal(t, "X-Tenant", httpError.Errors[1].Name)
+		require.Equal(t, "header", httpError.Errors[1].More["in"])
+		require.Equal(t, "query", httpError.Errors[0].In)
+		require.Equal(t, "header", httpError.Errors[1].In)
+		require.Empty(t, httpError.Errors[1].Pointer)
+	})
+
+	t.Run("with body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/items/7", strings.NewReader(`{"d":true}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Tenant", "acme")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.JSONEq(t, `{"b":"acme","c":7,"d":true}`, w.Body.String())
+	})
+
+	t.Run("documents the parameters", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		operation := s.OpenApiSpec.Paths.Find("/items/{id}").Get
+		require.NotNil(t, operation)
+		require.Len(t, operation.Parameters, 6)
+
+		id := operation.Parameters.GetByInAndName(openapi3.ParameterInPath, "id")
+		require.NotNil(t, id)
+		require.True(t, id.Required)
+		require.True(t, id.Schema.Value.Type.Is(openapi3.TypeInteger))
+
+		limit := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "limit")
+		require.NotNil(t, limit)
+		require.False(t, limit.Required)
+		require.Equal(t, "Number of items", limit.Description)
+		require.Equal(t, "10", limit.Schema.Value.Default)
+		require.Equal(t, 1.0, *limit.Schema.Value.Min)
+		require.Equal(t, 100.0, *limit.Schema.Value.Max)
+
+		tags := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "tags")
+		require.NotNil(t, tags)
+		require.True(t, tags.Schema.Value.Type.Is(openapi3.TypeArray))
+		require.True(t, tags.Schema.Value.Items.Value.Type.Is(openapi3.TypeString))
+		require.Equal(t, uint64(5), *tags.Schema.Value.MaxItems)
+		require.Equal(t, `^[a-zA-Z]+$`, tags.Schema.Value.Items.Value.Pattern)
+
+		tenant := operation.Parameters.GetByInAndName(openapi3.ParameterInHeader, "X-Tenant")
+		require.NotNil(t, tenant)
+		require.True(t, tenant.Required)
+
+		require.NotNil(t, operation.Parameters.GetByInAndName(openapi3.ParameterInCookie, "session"))
+
+		debug := operation.Parameters.GetByInAndName(openapi3.ParameterInQuery, "debug")
+		require.NotNil(t, debug)
+		require.True(t, debug.Schema.Value.Type.Is(openapi3.TypeBoolean))
+
+		post := s.OpenApiSpec.Paths.Find("/items/{id}").Post
+		require.NotNil(t, post)
+		require.NotNil(t, post.RequestBody)
+		require.Len(t, post.Parameters, 6)
+	})
+}
+
+func TestDefaultParamValues(t *testing.T) {
+	type defaults struct {
+		Sort  string   `query:"sort" default:"name,asc"`
+		Tags  []string `query:"tags" default:"a,b"`
+		Limit *int     `query:"limit" default:"10"`
+		None  string   `query:"none"`
+	}
+	fieldOf := func(name string) reflect.StructField {
+		field, _ := reflect.TypeFor[defaults]().FieldByName(name)
+		return field
+	}
+
+	values, ok := defaultParamValues(fieldOf("Sort"))
+	require.True(t, ok)
+	require.Equal(t, []string{"name,asc"}, values, "scalar defaults are not split")
+
+	values, ok = defaultParamValues(fieldOf("Tags"))
+	require.True(t, ok)
+	require.Equal(t, []string{"a", "b"}, values)
+
+	values, ok = defaultParamValues(fieldOf("Limit"))
+	require.True(t, ok)
+	require.Equal(t, []string{"10"}, values)
+
+	_, ok = defaultParamValues(fieldOf("None"))
+	require.False(t, ok)
+}
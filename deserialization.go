@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/gorilla/schema"
 	"gopkg.in/yaml.v3"
@@ -46,7 +49,7 @@ func readJSON[B any](context context.Context, input io.Reader, options readOptio
 		dec.DisallowUnknownFields()
 	}
 
-	return read[B](context, dec)
+	return read[B](context, dec, options)
 }
 
 // ReadXML reads the request body as XML.
@@ -65,7 +68,7 @@ func readXML[B any](context context.Context, input io.Reader, options readOption
 		dec.Strict = true
 	}
 
-	return read[B](context, dec)
+	return read[B](context, dec, options)
 }
 
 // ReadYAML reads the request body as YAML.
@@ -84,14 +87,14 @@ func readYAML[B any](context context.Context, input io.Reader, options readOptio
 		dec.KnownFields(true)
 	}
 
-	return read[B](context, dec)
+	return read[B](context, dec, options)
 }
 
 type decoder interface {
 	Decode(v any) error
 }
 
-func read[B any](context context.Context, dec decoder) (B, error) {
+func read[B any](context context.Context, dec decoder, options readOptions) (B, error) {
 	var body B
 
 	err := dec.Decode(&body)
@@ -113,7 +116,7 @@ func read[B any](context context.Context, dec decoder) (B, error) {
 		}
 	}
 
-	err = validate(body)
+	err = validate(body, options)
 	if err != nil {
 		return body, err
 	}
@@ -121,6 +124,43 @@ func read[B any](context context.Context, dec decoder) (B, error) {
 	return body, nil
 }
 
+// decodeBody decodes the request body with the codec, then transforms and validates it.
+func decodeBody[B any](r *http.Request, codec Codec, mediaType string, options readOptions) (B, error) {
+	var body B
+
+	if !canDecode(codec, reflect.TypeFor[B]()) {
+		return body, UnsupportedMediaTypeError{
+			Err:    fmt.Errorf("cannot decode %s into %T", mediaType, body),
+			Detail: fmt.Sprintf("cannot decode %s request body", mediaType),
+		}
+	}
+
+	err := codec.Decode(r, &body, DecodeOptions{
+		DisallowUnknownFields: options.DisallowUnknownFields,
+		MaxFileSize:           options.MaxFileSize,
+	})
+	if err != nil {
+		var errorWithStatus ErrorWithStatus
+		if errors.As(err, &errorWithStatus) {
+			return body, err
+		}
+		return body, BadRequestError{
+			Title:  "Decoding Failed",
+			Err:    err,
+			Detail: "cannot decode request body: " + err.Error(),
+		}
+	}
+	slog.Debug("Decoded body", "body", body)
+
+	body, err = transform(r.Context(), body)
+	if err != nil {
+		return body, err
+	}
+
+	options.FieldTag = codecFieldTag(codec)
+	return body, validate(body, options)
+}
+
 // ReadString reads the request body as string.
 // Can be used independently of Fuego framework.
 // Customizable by modifying ReadOptions.
@@ -184,26 +224,166 @@ func readURLEncoded[B any](r *http.Request, options readOptions) (B, error) {
 		return body, fmt.Errorf("cannot parse form: %w", err)
 	}
 
-	decoder := newDecoder()
-	decoder.IgnoreUnknownKeys(!options.DisallowUnknownFields)
+	return decodeForm[B](r.Context(), "x-www-form-urlencoded", r.PostForm, nil, options)
+}
+
+// multipartMemory is the maximum size of the multipart/form-data bodies kept in memory.
+// The uploaded files exceeding it are stored in temporary files.
+const multipartMemory = 32 << 20
 
-	err = decoder.Decode(&body, r.PostForm)
+// ReadMultipart reads the request body as multipart/form-data, including the uploaded files.
+func ReadMultipart[B any](r *http.Request) (B, error) {
+	return readMultipart[B](r, ReadOptions)
+}
+
+// readMultipart reads the request body as multipart/form-data.
+// The form values are decoded like [readURLEncoded], and the uploaded files are set to the
+// *multipart.FileHeader and []*multipart.FileHeader fields, named like the other fields.
+// Can be used independently of framework using [ReadMultipart],
+// or as a method of Context.
+func readMultipart[B any](r *http.Request, options readOptions) (B, error) {
+	var body B
+
+	err := parseMultipartForm(r, options.MaxFileSize)
 	if err != nil {
-		return body, BadRequestError{
-			Detail: "cannot decode x-www-form-urlencoded request body: " + err.Error(),
+		return body, err
+	}
+
+	return decodeForm[B](r.Context(), "multipart/form-data", r.MultipartForm.Value, r.MultipartForm.File, options)
+}
+
+// parseMultipartForm parses the multipart/form-data request body.
+// If maxFileSize is positive, the uploaded files are limited to this size while reading them,
+// so the larger files are rejected before being stored.
+// The temporary files of the form are removed once the request is handled, see [removeMultipartForm].
+func parseMultipartForm(r *http.Request, maxFileSize int64) error {
+	if maxFileSize > 0 && r.MultipartForm == nil {
+		_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if boundary := params["boundary"]; boundary != "" {
+			body := limitMultipartFiles(r.Body, boundary, maxFileSize)
+			defer body.Close()
+			r.Body = body
+		}
+	}
+
+	err := r.ParseMultipartForm(multipartMemory)
+	if err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			return HTTPError{
+				Err:    err,
+				Status: http.StatusRequestEntityTooLarge,
+				Title:  "Payload Too Large",
+				Detail: fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxBytesError.Limit),
+			}
+		}
+		var fileTooLarge *fileTooLargeError
+		if errors.As(err, &fileTooLarge) {
+			return HTTPError{
+				Err:    err,
+				Status: http.StatusRequestEntityTooLarge,
+				Title:  "Payload Too Large",
+				Detail: fmt.Sprintf("file %s exceeds the maximum size of %d bytes", fileTooLarge.filename, fileTooLarge.limit),
+				Errors: []ErrorItem{{Name: fileTooLarge.name, Reason: fmt.Sprintf("file %s is more than %d bytes long", fileTooLarge.filename, fileTooLarge.limit)}},
+			}
+		}
+		return BadRequestError{
+			Detail: "cannot parse multipart/form-data request body: " + err.Error(),
 			Err:    err,
-			Errors: []ErrorItem{
-				{Name: "form", Reason: "check that the form is valid, and that the content-type is correct"},
-			},
 		}
 	}
+
+	return nil
+}
+
+// fileTooLargeError is returned while reading an uploaded file larger than the maximum file size.
+type fileTooLargeError struct {
+	name, filename string
+	limit          int64
+}
+
+func (e *fileTooLargeError) Error() string {
+	return fmt.Sprintf("file %s of field %s exceeds the maximum size of %d bytes", e.filename, e.name, e.limit)
+}
+
+// limitMultipartFiles returns the multipart body with the same boundary, failing with a [*fileTooLargeError]
+// as soon as an uploaded file exceeds the limit. The parts are copied as they are read, without buffering them.
+// Closing the returned body stops the copy.
+func limitMultipartFiles(body io.Reader, boundary string, limit int64) io.ReadCloser {
+	pr, pw := io.Pipe()
+
+	go func() {
+		reader := multipart.NewReader(body, boundary)
+		writer := multipart.NewWriter(pw)
+		_ = writer.SetBoundary(boundary)
+
+		for {
+			part, err := reader.NextRawPart()
+			if errors.Is(err, io.EOF) {
+				pw.CloseWithError(writer.Close())
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			partWriter, err := writer.CreatePart(part.Header)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+
+			content := io.Reader(part)
+			if part.FileName() != "" {
+				content = io.LimitReader(part, limit+1)
+			}
+			n, err := io.Copy(partWriter, content)
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if part.FileName() != "" && n > limit {
+				pw.CloseWithError(&fileTooLargeError{name: part.FormName(), filename: part.FileName(), limit: limit})
+				return
+			}
+		}
+	}()
+
+	return pr
+}
+
+// removeMultipartForm removes the temporary files of the multipart form parsed while handling the request.
+// The [http.Server] only removes the ones of the form of the original request,
+// not the ones parsed on the copies made by the middlewares and by [Server.ServeHTTP].
+func removeMultipartForm(r *http.Request) {
+	if r.MultipartForm == nil {
+		return
+	}
+	err := r.MultipartForm.RemoveAll()
+	if err != nil {
+		slog.Warn("Cannot remove the temporary files of the multipart form", "error", err)
+	}
+}
+
+// decodeForm decodes the form values and files into the body, then transforms and validates it.
+func decodeForm[B any](context context.Context, formType string, values map[string][]string, files map[string][]*multipart.FileHeader, options readOptions) (B, error) {
+	var body B
+
+	err := decodeFormValues(&body, formType, values, files, DecodeOptions{
+		DisallowUnknownFields: options.DisallowUnknownFields,
+		MaxFileSize:           options.MaxFileSize,
+	})
+	if err != nil {
+		return body, err
+	}
 	slog.Debug("Decoded body", "body", body)
 
-	body, err = transform(r.Context(), body)
+	body, err = transform(context, body)
 	if err != nil {
 		return body, BadRequestError{
 			Title:  "Transformation Failed",
-			Detail: "cannot transform x-www-form-urlencoded request body: " + err.Error(),
+			Detail: "cannot transform " + formType + " request body: " + err.Error(),
 			Err:    err,
 			Errors: []ErrorItem{
 				{Name: "transformation", Reason: "transformation failed"},
@@ -211,7 +391,8 @@ func readURLEncoded[B any](r *http.Request, options readOptions) (B, error) {
 		}
 	}
 
-	err = validate(body)
+	options.FieldTag = "schema"
+	err = validate(body, options)
 	if err != nil {
 		return body, fmt.Errorf("cannot validate request body: %w", err)
 	}
@@ -219,6 +400,132 @@ func readURLEncoded[B any](r *http.Request, options readOptions) (B, error) {
 	return body, nil
 }
 
+// decodeFormValues decodes the form values and files into v, a pointer.
+func decodeFormValues(v any, formType string, values map[string][]string, files map[string][]*multipart.FileHeader, options DecodeOptions) error {
+	decoder := newDecoder()
+	decoder.IgnoreUnknownKeys(!options.DisallowUnknownFields)
+
+	err := decoder.Decode(v, values)
+	if err != nil {
+		return BadRequestError{
+			Detail: "cannot decode " + formType + " request body: " + err.Error(),
+			Err:    err,
+			Errors: []ErrorItem{
+				{Name: "form", Reason: "check that the form is valid, and that the content-type is correct"},
+			},
+		}
+	}
+
+	return setFormFiles(v, files, options)
+}
+
+// formFileField is a body field receiving uploaded files.
+type formFileField struct {
+	index []int
+	name  string
+	multi bool // []*multipart.FileHeader field
+}
+
+var (
+	fileHeaderPtrType   = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeaderSliceType = reflect.TypeFor[[]*multipart.FileHeader]()
+)
+
+// formFileFields returns the *multipart.FileHeader and []*multipart.FileHeader fields of the struct type,
+// including the ones of embedded structs. They are named by their schema tag, like the other form fields.
+func formFileFields(t reflect.Type) []formFileField {
+	if t == nil {
+		return nil
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := []formFileField{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for _, embedded := range formFileFields(field.Type) {
+				embedded.index = append([]int{i}, embedded.index...)
+				fields = append(fields, embedded)
+			}
+			continue
+		}
+
+		if field.Type != fileHeaderPtrType && field.Type != fileHeaderSliceType {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("schema"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, formFileField{index: []int{i}, name: name, multi: field.Type == fileHeaderSliceType})
+	}
+
+	return fields
+}
+
+// setFormFiles sets the uploaded files to the file fields of the body, enforcing the maximum file size.
+func setFormFiles(body any, files map[string][]*multipart.FileHeader, options DecodeOptions) error {
+	fields := formFileFields(reflect.TypeOf(body).Elem())
+
+	known := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		known[field.name] = true
+	}
+
+	var errorItems []ErrorItem
+	for name, headers := range files {
+		if !known[name] && options.DisallowUnknownFields {
+			errorItems = append(errorItems, ErrorItem{Name: name, Reason: "unknown file field"})
+		}
+
+		for _, header := range headers {
+			if options.MaxFileSize > 0 && header.Size > options.MaxFileSize {
+				return HTTPError{
+					Status: http.StatusRequestEntityTooLarge,
+					Title:  "Payload Too Large",
+					Detail: fmt.Sprintf("file %s exceeds the maximum size of %d bytes", header.Filename, options.MaxFileSize),
+					Errors: []ErrorItem{{Name: name, Reason: fmt.Sprintf("file %s is %d bytes long", header.Filename, header.Size)}},
+				}
+			}
+		}
+	}
+
+	if len(errorItems) > 0 {
+		return BadRequestError{
+			Detail: "cannot decode multipart/form-data request body: unknown file fields",
+			Err:    errors.New("unknown file fields"),
+			Errors: errorItems,
+		}
+	}
+
+	bodyValue := reflect.ValueOf(body).Elem()
+	for _, field := range fields {
+		headers := files[field.name]
+		if len(headers) == 0 {
+			continue
+		}
+
+		fieldValue := bodyValue.FieldByIndex(field.index)
+		if field.multi {
+			fieldValue.Set(reflect.ValueOf(headers))
+		} else {
+			fieldValue.Set(reflect.ValueOf(headers[0]))
+		}
+	}
+
+	return nil
+}
+
 // transforms the input if possible.
 func transform[B any](ctx context.Context, body B) (B, error) {
 	if inTransformerBody, ok := any(&body).(InTransformer); ok {
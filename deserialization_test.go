@@ -6,8 +6,12 @@ import (
 	"database/sql"
 	"encoding/xml"
 	"errors"
+	"fmt"
 	"io"
+	"mime/multipart"
+	"net/http"
 	"net/http/httptest"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -269,6 +273,152 @@ func TestReadURLEncoded(t *testing.T) {
 	})
 }
 
+type uploadBody struct {
+	Title       string                  `schema:"title" validate:"required"`
+	Avatar      *multipart.FileHeader   `schema:"avatar"`
+	Attachments []*multipart.FileHeader `schema:"attachments"`
+}
+
+func newMultipartRequest(t *testing.T, fields map[string]string, files map[string][]string) *http.Request {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		require.NoError(t, w.WriteField(name, value))
+	}
+	for name, contents := range files {
+		for i, content := range contents {
+			part, err := w.CreateFormFile(name, fmt.Sprintf("%s-%d.txt", name, i))
+			require.NoError(t, err)
+			_, err = part.Write([]byte(content))
+			require.NoError(t, err)
+		}
+	}
+	require.NoError(t, w.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/", &buf)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestReadMultipart(t *testing.T) {
+	t.Run("read values and files", func(t *testing.T) {
+		r := newMultipartRequest(t,
+			map[string]string{"title": "holidays"},
+			map[string][]string{"avatar": {"me"}, "attachments": {"first", "second"}},
+		)
+
+		body, err := ReadMultipart[uploadBody](r)
+		require.NoError(t, err)
+		require.Equal(t, "holidays", body.Title)
+		require.NotNil(t, body.Avatar)
+		require.Equal(t, "avatar-0.txt", body.Avatar.Filename)
+		require.Len(t, body.Attachments, 2)
+
+		file, err := body.Attachments[1].Open()
+		require.NoError(t, err)
+		defer file.Close()
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		require.Equal(t, "second", string(content))
+	})
+
+	t.Run("validates the values", func(t *testing.T) {
+		r := newMultipartRequest(t, nil, map[string][]string{"avatar": {"me"}})
+
+		_, err := ReadMultipart[uploadBody](r)
+		require.Error(t, err)
+	})
+
+	t.Run("rejects unknown files", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"unknown": {"?"}})
+
+		_, err := ReadMultipart[uploadBody](r)
+		require.ErrorAs(t, err, &BadRequestError{})
+	})
+
+	t.Run("enforces the maximum file size", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"avatar": {"too long"}})
+
+		_, err := readMultipart[uploadBody](r, readOptions{MaxFileSize: 4})
+		var httpError HTTPError
+		require.ErrorAs(t, err, &httpError)
+		require.Equal(t, http.StatusRequestEntityTooLarge, httpError.StatusCode())
+	})
+
+	t.Run("enforces the maximum file size while reading", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"avatar": {strings.Repeat("a", 1<<20)}})
+		body := &countingReader{r: r.Body}
+		r.Body = io.NopCloser(body)
+
+		_, err := readMultipart[uploadBody](r, readOptions{MaxFileSize: 4})
+		var httpError HTTPError
+		require.ErrorAs(t, err, &httpError)
+		require.Equal(t, http.StatusRequestEntityTooLarge, httpError.StatusCode())
+		require.Less(t, body.n, 1<<16, "the file is not read past the maximum size")
+	})
+
+	t.Run("removes the temporary files once the request is handled", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Setenv("TMPDIR", dir)
+
+		s := NewServer(WithoutLogger())
+		Post(s.RouterGroup(), "/upload", func(c *ContextWithBody[uploadBody]) (string, error) {
+			body, err := c.Body()
+			if err != nil {
+				return "", err
+			}
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.NotEmpty(t, entries, "the large files are stored in temporary files")
+			return body.Avatar.Filename, nil
+		})
+
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"avatar": {strings.Repeat("a", multipartMemory+1)}})
+		r.URL.Path = "/upload"
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("enforces the maximum body size through the context", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"avatar": {strings.Repeat("a", 2048)}})
+
+		c := NewContext[uploadBody](httptest.NewRecorder(), r, readOptions{MaxBodySize: 1024})
+		_, err := c.Body()
+		var httpError HTTPError
+		require.ErrorAs(t, err, &httpError)
+		require.Equal(t, http.StatusRequestEntityTooLarge, httpError.StatusCode())
+	})
+
+	t.Run("content type with boundary is read as multipart through the context", func(t *testing.T) {
+		r := newMultipartRequest(t, map[string]string{"title": "holidays"}, map[string][]string{"avatar": {"me"}})
+
+		c := NewContext[uploadBody](httptest.NewRecorder(), r, readOptions{})
+		body, err := c.Body()
+		require.NoError(t, err)
+		require.Equal(t, "holidays", body.Title)
+		require.NotNil(t, body.Avatar)
+	})
+}
+
+// countingReader counts the bytes read.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
 func TestConvertSQLNullString(t *testing.T) {
 	t.Run("can convert sql.NullString", func(t *testing.T) {
 		v := convertSQLNullString("test")
@@ -1,10 +1,12 @@
 package fuego
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 )
 
 // ErrorWithStatus is an interface that can be implemented by an error to provide
@@ -17,15 +19,19 @@ type ErrorWithStatus interface {
 // HTTPError is the error response used by the serialization part of the framework.
 type HTTPError struct {
 	// Developer readable error message. Not shown to the user to avoid security leaks.
-	Err error `json:"-" xml:"-"`
+	Err error `json:"-" xml:"-" yaml:"-"`
+	// URI identifying the problem type. If empty, set from the problem types registered with [WithProblemType] when serialized.
+	Type string `json:"type,omitempty" xml:"type,omitempty" yaml:"type,omitempty" description:"URI identifying the problem type" example:"https://www.rfc-editor.org/rfc/rfc9110#section-15.5.4"`
 	// Short title of the error
-	Title string `json:"title,omitempty" xml:"title,omitempty" description:"Short title of the error" example:"failed to fetch details"`
+	Title string `json:"title,omitempty" xml:"title,omitempty" yaml:"title,omitempty" description:"Short title of the error" example:"failed to fetch details"`
 	// HTTP status code. If using a different type than [HTTPError], for example [BadRequestError], this will be automatically overridden after Fuego error handling.
-	Status int `json:"status,omitempty" xml:"status,omitempty" description:"HTTP status code" example:"403"`
+	Status int `json:"status,omitempty" xml:"status,omitempty" yaml:"status,omitempty" description:"HTTP status code" example:"403"`
 	// Human readable error message
-	Detail   string      `json:"detail,omitempty" xml:"detail,omitempty" description:"Human readable error message" example:"details cannot be loaded"`
-	Instance string      `json:"instance,omitempty" xml:"instance,omitempty"`
-	Errors   []ErrorItem `json:"errors,omitempty" xml:"errors,omitempty"`
+	Detail   string      `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty" description:"Human readable error message" example:"details cannot be loaded"`
+	Instance string      `json:"instance,omitempty" xml:"instance,omitempty" yaml:"instance,omitempty"`
+	Errors   []ErrorItem `json:"errors,omitempty" xml:"errors,omitempty" yaml:"errors,omitempty"`
+	// Delay after which the client can retry, sent in the Retry-After header. Ignored if zero.
+	RetryAfter time.Duration `json:"-" xml:"-" yaml:"-"`
 }
 
 func (HTTPError) OpenApiName() string {
@@ -33,9 +39,11 @@ func (HTTPError) OpenApiName() string {
 }
 
 type ErrorItem struct {
-	Name   string         `json:"name" xml:"name" description:"For example, name of the parameter that caused the error"`
-	Reason string         `json:"reason" xml:"reason" description:"Human readable error message"`
-	More   map[string]any `json:"more,omitempty" xml:"more,omitempty" description:"Additional information about the error"`
+	Name    string         `json:"name" xml:"name" yaml:"name" description:"For example, name of the parameter that caused the error"`
+	Reason  string         `json:"reason" xml:"reason" yaml:"reason" description:"Human readable error message"`
+	Pointer string         `json:"pointer,omitempty" xml:"pointer,omitempty" yaml:"pointer,omitempty" description:"JSON Pointer (RFC 6901) to the invalid field of the request body"`
+	In      string         `json:"in,omitempty" xml:"in,omitempty" yaml:"in,omitempty" description:"Location of the invalid value: body, query, header, cookie or path"`
+	More    map[string]any `json:"more,omitempty" xml:"more,omitempty" yaml:"more,omitempty" description:"Additional information about the error"`
 }
 
 func (e HTTPError) Error() string {
@@ -49,6 +57,15 @@ func (e HTTPError) Error() string {
 	return fmt.Sprintf("%s (%d): %s", title, e.Status, e.Detail)
 }
 
+// message returns the message of the underlying error, or the HTTP error summary if there is none.
+// Used by the error types derived from [HTTPError].
+func (e HTTPError) message() string {
+	if e.Err == nil {
+		return e.Error()
+	}
+	return e.Err.Error()
+}
+
 func (e HTTPError) StatusCode() int {
 	if e.Status == 0 {
 		return http.StatusInternalServerError
@@ -63,7 +80,7 @@ type BadRequestError HTTPError
 
 var _ ErrorWithStatus = BadRequestError{}
 
-func (e BadRequestError) Error() string { return e.Err.Error() }
+func (e BadRequestError) Error() string { return HTTPError(e).message() }
 
 func (e BadRequestError) StatusCode() int { return http.StatusBadRequest }
 
@@ -74,7 +91,7 @@ type NotFoundError HTTPError
 
 var _ ErrorWithStatus = NotFoundError{}
 
-func (e NotFoundError) Error() string { return e.Err.Error() }
+func (e NotFoundError) Error() string { return HTTPError(e).message() }
 
 func (e NotFoundError) StatusCode() int { return http.StatusNotFound }
 
@@ -85,7 +102,7 @@ type UnauthorizedError HTTPError
 
 var _ ErrorWithStatus = UnauthorizedError{}
 
-func (e UnauthorizedError) Error() string { return e.Err.Error() }
+func (e UnauthorizedError) Error() string { return HTTPError(e).message() }
 
 func (e UnauthorizedError) StatusCode() int { return http.StatusUnauthorized }
 
@@ -96,7 +113,7 @@ type ForbiddenError HTTPError
 
 var _ ErrorWithStatus = ForbiddenError{}
 
-func (e ForbiddenError) Error() string { return e.Err.Error() }
+func (e ForbiddenError) Error() string { return HTTPError(e).message() }
 
 func (e ForbiddenError) StatusCode() int { return http.StatusForbidden }
 
@@ -107,16 +124,141 @@ type ConflictError HTTPError
 
 var _ ErrorWithStatus = ConflictError{}
 
-func (e ConflictError) Error() string { return e.Err.Error() }
+func (e ConflictError) Error() string { return HTTPError(e).message() }
 
 func (e ConflictError) StatusCode() int { return http.StatusConflict }
 
 func (e ConflictError) Unwrap() error { return HTTPError(e) }
 
+// NotAcceptableError is an error used to return a 406 status code,
+// when the response cannot be serialized in any of the media types accepted by the client.
+type NotAcceptableError HTTPError
+
+var _ ErrorWithStatus = NotAcceptableError{}
+
+func (e NotAcceptableError) Error() string { return HTTPError(e).message() }
+
+func (e NotAcceptableError) StatusCode() int { return http.StatusNotAcceptable }
+
+func (e NotAcceptableError) Unwrap() error { return HTTPError(e) }
+
+// UnsupportedMediaTypeError is an error used to return a 415 status code,
+// when the request body is in a media type that cannot be decoded.
+type UnsupportedMediaTypeError HTTPError
+
+var _ ErrorWithStatus = UnsupportedMediaTypeError{}
+
+func (e UnsupportedMediaTypeError) Error() string { return HTTPError(e).message() }
+
+func (e UnsupportedMediaTypeError) StatusCode() int { return http.StatusUnsupportedMediaType }
+
+func (e UnsupportedMediaTypeError) Unwrap() error { return HTTPError(e) }
+
+// MethodNotAllowedError is an error used to return a 405 status code.
+type MethodNotAllowedError HTTPError
+
+var _ ErrorWithStatus = MethodNotAllowedError{}
+
+func (e MethodNotAllowedError) Error() string { return HTTPError(e).message() }
+
+func (e MethodNotAllowedError) StatusCode() int { return http.StatusMethodNotAllowed }
+
+func (e MethodNotAllowedError) Unwrap() error { return HTTPError(e) }
+
+// UnprocessableEntityError is an error used to return a 422 status code,
+// when the request is well-formed but cannot be processed, for example because of business rules.
+type UnprocessableEntityError HTTPError
+
+var _ ErrorWithStatus = UnprocessableEntityError{}
+
+func (e UnprocessableEntityError) Error() string { return HTTPError(e).message() }
+
+func (e UnprocessableEntityError) StatusCode() int { return http.StatusUnprocessableEntity }
+
+func (e UnprocessableEntityError) Unwrap() error { return HTTPError(e) }
+
+// TooManyRequestsError is an error used to return a 429 status code.
+// Set RetryAfter to tell the client when to retry.
+type TooManyRequestsError HTTPError
+
+var _ ErrorWithStatus = TooManyRequestsError{}
+
+func (e TooManyRequestsError) Error() string { return HTTPError(e).message() }
+
+func (e TooManyRequestsError) StatusCode() int { return http.StatusTooManyRequests }
+
+func (e TooManyRequestsError) Unwrap() error { return HTTPError(e) }
+
+// ServiceUnavailableError is an error used to return a 503 status code.
+// Set RetryAfter to tell the client when to retry.
+type ServiceUnavailableError HTTPError
+
+var _ ErrorWithStatus = ServiceUnavailableError{}
+
+func (e ServiceUnavailableError) Error() string { return HTTPError(e).message() }
+
+func (e ServiceUnavailableError) StatusCode() int { return http.StatusServiceUnavailable }
+
+func (e ServiceUnavailableError) Unwrap() error { return HTTPError(e) }
+
+// StatusClientClosedRequest is the non-standard status code of the requests canceled by the client
+// before the response is written. Nothing is written for them.
+const StatusClientClosedRequest = 499
+
+// errorStatus returns the status code of the error, 500 if it does not implement [ErrorWithStatus].
+func errorStatus(err error) int {
+	var errorWithStatus ErrorWithStatus
+	if errors.As(err, &errorWithStatus) {
+		return errorWithStatus.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// classifyError gives their status code to the errors of the standard library,
+// when they are returned as is or wrapped in a generic 400 or 500 error, like the body reading errors:
+//   - [http.MaxBytesError]: 413 Request Entity Too Large
+//   - [context.DeadlineExceeded]: 504 Gateway Timeout
+//   - [context.Canceled]: 499 Client Closed Request
+func classifyError(err error) error {
+	if status := errorStatus(err); status != http.StatusBadRequest && status != http.StatusInternalServerError {
+		return err
+	}
+
+	var maxBytesError *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesError):
+		return HTTPError{
+			Err:    err,
+			Status: http.StatusRequestEntityTooLarge,
+			Title:  "Payload Too Large",
+			Detail: fmt.Sprintf("request body exceeds the maximum size of %d bytes", maxBytesError.Limit),
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		return HTTPError{
+			Err:    err,
+			Status: http.StatusGatewayTimeout,
+			Detail: "the request took too long to be processed",
+		}
+	case errors.Is(err, context.Canceled):
+		return HTTPError{
+			Err:    err,
+			Status: StatusClientClosedRequest,
+			Title:  "Client Closed Request",
+		}
+	default:
+		return err
+	}
+}
+
 // ErrorHandler is the default error handler used by the framework.
 // It transforms any error into the unified error type [HTTPError],
 // Using the [ErrorWithStatus] and [ErrorWithInfo] interfaces.
+// The Title is left empty when the error has none: it is set when the error is serialized,
+// from the problem type registered with [WithProblemType], or from the status text.
+// Custom error serializers reading it must handle the empty title.
 func ErrorHandler(err error) error {
+	err = classifyError(err)
+
 	errResponse := HTTPError{
 		Err: err,
 	}
@@ -124,20 +266,21 @@ func ErrorHandler(err error) error {
 	var errorInfo HTTPError
 	if errors.As(err, &errorInfo) {
 		errResponse = errorInfo
+		// Keep the original error, so its kind still gives its problem type when serialized.
+		if _, ok := err.(HTTPError); !ok {
+			errResponse.Err = err
+		}
 	}
 
-	// Check status code
-	errResponse.Status = http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		errResponse.Status = errorStatus.StatusCode()
-	}
+	errResponse.Status = errorStatus(err)
 
-	if errResponse.Title == "" {
-		errResponse.Title = http.StatusText(errResponse.Status)
+	// The empty titles are set when serialized, from the problem type of the error.
+	title := errResponse.Title
+	if title == "" {
+		title = http.StatusText(errResponse.Status)
 	}
 
-	slog.Error("Error "+errResponse.Title, "status", errResponse.StatusCode(), "detail", errResponse.Detail, "error", errResponse.Err)
+	slog.Error("Error "+title, "status", errResponse.StatusCode(), "detail", errResponse.Detail, "error", errResponse.Err)
 
 	return errResponse
 }
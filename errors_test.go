@@ -1,9 +1,14 @@
 package fuego
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -85,6 +90,121 @@ func TestErrorHandler(t *testing.T) {
 		require.Contains(t, errResponse.Error(), "403")
 		require.Equal(t, http.StatusForbidden, errResponse.(HTTPError).StatusCode())
 	})
+
+	t.Run("body too large", func(t *testing.T) {
+		err := BadRequestError{Err: &http.MaxBytesError{Limit: 10}}
+		errResponse := ErrorHandler(err)
+		require.Equal(t, http.StatusRequestEntityTooLarge, errResponse.(HTTPError).StatusCode())
+		require.Contains(t, errResponse.(HTTPError).Detail, "10 bytes")
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		err := fmt.Errorf("querying: %w", context.DeadlineExceeded)
+		errResponse := ErrorHandler(err)
+		require.Equal(t, http.StatusGatewayTimeout, errResponse.(HTTPError).StatusCode())
+		require.ErrorIs(t, errResponse, context.DeadlineExceeded)
+	})
+
+	t.Run("canceled request", func(t *testing.T) {
+		errResponse := ErrorHandler(context.Canceled)
+		require.Equal(t, StatusClientClosedRequest, errResponse.(HTTPError).StatusCode())
+	})
+
+	t.Run("keeps the status of explicit errors", func(t *testing.T) {
+		err := ServiceUnavailableError{Err: context.DeadlineExceeded}
+		errResponse := ErrorHandler(err)
+		require.Equal(t, http.StatusServiceUnavailable, errResponse.(HTTPError).StatusCode())
+	})
+
+	t.Run("leaves the title to the problem type", func(t *testing.T) {
+		errResponse := ErrorHandler(outOfStockError{})
+		require.Empty(t, errResponse.(HTTPError).Title)
+		require.Contains(t, errResponse.Error(), "Conflict (409)")
+
+		errResponse = ErrorHandler(NotFoundError{Title: "Recipe Not Found"})
+		require.Equal(t, "Recipe Not Found", errResponse.(HTTPError).Title)
+
+		s := NewServer(
+			WithoutLogger(),
+			WithProblemType(outOfStockError{}, ProblemType{Title: "Out of stock"}),
+		)
+		Get(s.RouterGroup(), "/stock", func(c ContextNoBody) (any, error) {
+			return nil, outOfStockError{}
+		})
+		Get(s.RouterGroup(), "/missing", func(c ContextNoBody) (any, error) {
+			return nil, NotFoundError{}
+		})
+
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stock", nil))
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.Contains(t, w.Body.String(), `"title":"Out of stock"`)
+
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/missing", nil))
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Contains(t, w.Body.String(), `"title":"Not Found"`)
+	})
+}
+
+func TestErrorStatuses(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithMaxBodySize(10),
+	)
+
+	Post(s.RouterGroup(), "/body", func(c *ContextWithBody[MyStruct]) (MyStruct, error) {
+		return c.Body()
+	})
+
+	Get(s.RouterGroup(), "/rate-limited", func(c ContextNoBody) (any, error) {
+		return nil, TooManyRequestsError{Detail: "slow down", RetryAfter: 1500 * time.Millisecond}
+	})
+
+	Get(s.RouterGroup(), "/canceled", func(c ContextNoBody) (any, error) {
+		return nil, fmt.Errorf("querying: %w", c.Context().Err())
+	})
+
+	t.Run("body too large", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/body", strings.NewReader(`{"b":"a very long string"}`))
+		r.Header.Set("Content-Type", "application/json")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("unsupported media type", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/body", strings.NewReader(`b`))
+		r.Header.Set("Content-Type", "application/x-unknown")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		require.Contains(t, w.Body.String(), "application/json")
+	})
+
+	t.Run("retry after", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/rate-limited", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "2", w.Header().Get("Retry-After"))
+		require.Contains(t, w.Body.String(), "slow down")
+	})
+
+	t.Run("canceled request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequestWithContext(ctx, http.MethodGet, "/canceled", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, StatusClientClosedRequest, w.Code)
+		require.Empty(t, w.Body.String())
+	})
 }
 
 func TestHTTPError_Error(t *testing.T) {
@@ -117,3 +237,57 @@ func TestHTTPError_Unwrap(t *testing.T) {
 	require.True(t, errors.As(errResponse.Unwrap(), &unwrapped))
 	require.Equal(t, 999, unwrapped.status)
 }
+
+func TestContextErrorHandler(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithErrorSerializer(SendError),
+		WithContextErrorHandler(func(ctx ContextNoBody, err error) error {
+			httpError := ErrorHandler(err).(HTTPError)
+			httpError.Instance = ctx.Request().URL.Path
+			return httpError
+		}),
+	)
+
+	Get(s.RouterGroup(), "/books/:id", func(c ContextNoBody) (any, error) {
+		return nil, NotFoundError{Detail: "no such book"}
+	})
+
+	rejectAll := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			HandleError(w, r, ForbiddenError{Detail: "go away"})
+		})
+	}
+	Get(s.RouterGroup(), "/private", func(c ContextNoBody) (any, error) {
+		return "secret", nil
+	}, WrapMiddleware(rejectAll))
+
+	t.Run("transforms the errors of the controllers with the request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/books/42", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Contains(t, w.Body.String(), `"instance":"/books/42"`)
+	})
+
+	t.Run("transforms the errors of the standard middlewares", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/private", nil)
+		r.Header.Set("Accept", "application/xml")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "<instance>/private</instance>")
+	})
+
+	t.Run("handles errors outside of a server", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		HandleError(w, r, ForbiddenError{Detail: "go away"})
+
+		require.Equal(t, http.StatusForbidden, w.Code)
+		require.NotContains(t, w.Body.String(), "instance")
+	})
+}
@@ -0,0 +1,159 @@
+package fuego
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"time"
+)
+
+// File is a controller return type sending a seekable content, like a file.
+// It sets the Content-Type, Content-Length, Content-Disposition and Last-Modified headers,
+// and answers conditional requests (If-Modified-Since, If-None-Match...) and range requests
+// with single or multi-range 206 Partial Content responses.
+// The content is closed once sent if it implements [io.Closer].
+// For example:
+//
+//	fuego.Get(s.RouterGroup(), "/reports/:id", func(c fuego.ContextNoBody) (fuego.File, error) {
+//		return fuego.FileFromFS(reportsFS, c.PathParam("id")+".pdf")
+//	})
+//
+// The route is documented with a binary application/octet-stream response.
+type File struct {
+	Name        string        // File name sent in the Content-Disposition header. Its extension is also used to detect the Content-Type.
+	Content     io.ReadSeeker // Content of the file.
+	ContentType string        // If empty, detected from the file name extension, or from the content.
+	ModTime     time.Time     // Sent in the Last-Modified header, and used for conditional requests. Ignored if zero.
+	Inline      bool          // If true, the browser is asked to display the file instead of downloading it.
+}
+
+// FileFromFS opens the file at the given path of the filesystem, to be returned by a controller.
+// It returns a [NotFoundError] if the file does not exist or is a directory.
+func FileFromFS(fsys fs.FS, name string) (File, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return File{}, NotFoundError{
+				Title:  "File Not Found",
+				Detail: "file " + name + " does not exist",
+				Err:    err,
+			}
+		}
+		return File{}, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return File{}, err
+	}
+
+	if info.IsDir() {
+		_ = f.Close()
+		return File{}, NotFoundError{
+			Title:  "File Not Found",
+			Detail: "file " + name + " is a directory",
+			Err:    fmt.Errorf("%s is a directory", name),
+		}
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		_ = f.Close()
+		return File{}, fmt.Errorf("file %s of %T cannot seek, use a Stream to send it", name, fsys)
+	}
+
+	return File{
+		Name:    path.Base(name),
+		Content: content,
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+func (f File) describeResponse() (any, string) {
+	return binaryContent{}, "application/octet-stream"
+}
+
+func (f File) respond(w http.ResponseWriter, r *http.Request) error {
+	if f.Content == nil {
+		return errors.New("file has no content")
+	}
+	if closer, ok := f.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	setContentHeaders(w, f.Name, f.ContentType, f.Inline)
+	http.ServeContent(w, r, f.Name, f.ModTime, f.Content)
+	return nil
+}
+
+// Stream is a controller return type sending a content of unknown length, read until [io.EOF].
+// Unlike [File], range and conditional requests are not supported.
+// The content is closed once sent if it implements [io.Closer].
+// If reading the content fails once the download has started, the connection is aborted,
+// so the client does not keep a truncated file.
+// For example:
+//
+//	fuego.Get(s.RouterGroup(), "/exports/users.csv", func(c fuego.ContextNoBody) (fuego.Stream, error) {
+//		return fuego.Stream{Name: "users.csv", Content: exportUsers(c.Context())}, nil
+//	})
+//
+// The route is documented with a binary application/octet-stream response.
+type Stream struct {
+	Name        string    // File name sent in the Content-Disposition header, if any. Its extension is also used to detect the Content-Type.
+	Content     io.Reader // Content of the stream.
+	ContentType string    // If empty, detected from the file name extension, or application/octet-stream.
+	Inline      bool      // If true, the browser is asked to display the content instead of downloading it.
+}
+
+func (s Stream) describeResponse() (any, string) {
+	return binaryContent{}, "application/octet-stream"
+}
+
+func (s Stream) respond(w http.ResponseWriter, _ *http.Request) error {
+	if s.Content == nil {
+		return errors.New("stream has no content")
+	}
+	if closer, ok := s.Content.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	setContentHeaders(w, s.Name, s.ContentType, s.Inline)
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+
+	_, err := io.Copy(w, s.Content)
+	if err != nil {
+		return fmt.Errorf("cannot send stream: %w", err)
+	}
+	return nil
+}
+
+// setContentHeaders sets the Content-Type and Content-Disposition headers of a downloaded content.
+func setContentHeaders(w http.ResponseWriter, name, contentType string, inline bool) {
+	if contentType == "" && name != "" {
+		contentType = mime.TypeByExtension(path.Ext(name))
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if name != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": path.Base(name)}))
+	} else {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+}
+
+// binaryContent documents binary payloads, like the ones of [File] and [Stream],
+// as strings with the binary format.
+type binaryContent struct{}
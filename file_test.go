@@ -0,0 +1,147 @@
+package fuego
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"reports/2024.txt":   {Data: []byte("0123456789"), ModTime: modTime},
+		"reports/résumé.pdf": {Data: []byte("%PDF"), ModTime: modTime},
+	}
+
+	s := NewServer(WithoutLogger())
+	Get(s.RouterGroup(), "/reports/:name", func(c ContextNoBody) (File, error) {
+		return FileFromFS(files, "reports/"+c.PathParam("name"))
+	})
+	Get(s.RouterGroup(), "/inline", func(c ContextNoBody) (File, error) {
+		return File{Content: strings.NewReader("hello"), Name: "hello.html", Inline: true}, nil
+	})
+	Get(s.RouterGroup(), "/stream", func(c ContextNoBody) (Stream, error) {
+		return Stream{Name: "export.csv", Content: strings.NewReader("a,b\n1,2\n")}, nil
+	})
+	Get(s.RouterGroup(), "/broken-stream", func(c ContextNoBody) (Stream, error) {
+		return Stream{Name: "export.csv", Content: io.MultiReader(strings.NewReader("a,b\n"), iotest.ErrReader(errors.New("disk failure")))}, nil
+	})
+
+	t.Run("sends the whole file", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/2024.txt", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "0123456789", w.Body.String())
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "10", w.Header().Get("Content-Length"))
+		require.Equal(t, `attachment; filename=2024.txt`, w.Header().Get("Content-Disposition"))
+		require.Equal(t, modTime.Format(http.TimeFormat), w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("encodes non ASCII file names", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/r%C3%A9sum%C3%A9.pdf", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+		require.Equal(t, `attachment; filename*=utf-8''r%C3%A9sum%C3%A9.pdf`, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("single range", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/2024.txt", nil)
+		r.Header.Set("Range", "bytes=2-4")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.Equal(t, "234", w.Body.String())
+		require.Equal(t, "bytes 2-4/10", w.Header().Get("Content-Range"))
+	})
+
+	t.Run("multiple ranges", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/2024.txt", nil)
+		r.Header.Set("Range", "bytes=0-1,8-9")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusPartialContent, w.Code)
+		require.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges; boundary="))
+		require.Contains(t, w.Body.String(), "Content-Range: bytes 0-1/10\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n01")
+		require.Contains(t, w.Body.String(), "Content-Range: bytes 8-9/10\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n89")
+	})
+
+	t.Run("conditional request", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/2024.txt", nil)
+		r.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotModified, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("file not found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/reports/1999.txt", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("inline content from a reader", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/inline", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "hello", w.Body.String())
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "inline; filename=hello.html", w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/stream", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "a,b\n1,2\n", w.Body.String())
+		require.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Equal(t, "attachment; filename=export.csv", w.Header().Get("Content-Disposition"))
+		require.Empty(t, w.Header().Get("Content-Length"))
+	})
+
+	t.Run("aborts the connection when the stream fails", func(t *testing.T) {
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/broken-stream")
+		if err == nil {
+			_, err = io.ReadAll(res.Body)
+			res.Body.Close()
+		}
+		require.Error(t, err, "the truncated file is not mistaken for the whole one")
+	})
+
+	t.Run("documents a binary response", func(t *testing.T) {
+		for _, path := range []string{"/reports/{name}", "/stream"} {
+			response := s.OpenApiSpec.Paths.Find(path).Get.Responses.Status(200)
+			require.NotNil(t, response)
+			mediaType := response.Value.Content.Get("application/octet-stream")
+			require.NotNil(t, mediaType, path)
+			require.True(t, mediaType.Schema.Value.Type.Is("string"))
+			require.Equal(t, "binary", mediaType.Schema.Value.Format)
+		}
+	})
+}
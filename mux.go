@@ -1,8 +1,11 @@
 package fuego
 
 import (
+	"bufio"
+	"net"
 	"net/http"
 	"net/url"
+	"reflect"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/gin-gonic/gin"
@@ -82,13 +85,22 @@ type Route struct {
 
 	Response Schema
 	Request  Schema
+	Params   any // Struct documenting the typed parameters of the route, see [ContextWithParams]
 	Errors   []openAPIError
+
+	DefaultStatusCode int              // Status code of successful responses, if the controller does not set it. Defaults to 200.
+	ResponseHeaders   openapi3.Headers // Headers documented on successful responses
+
+	entry     *Route // ref to the route stored in the server registry, kept up to date by the route methods
+	finalized bool   // true once the route is documented in the OpenAPI spec. Only meaningful on the registry entry
 }
 
 type Schema struct {
 	Type        any
 	Description string
 	ContentType []string
+
+	sequence reflect.Type // Item type of the iter.Seq[T] or channel response, documented as an array of T
 }
 
 // openAPIError describes a response error in the OpenAPI spec.
@@ -107,7 +119,7 @@ func All[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller fun
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
 
@@ -120,7 +132,7 @@ func Get[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller fun
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
@@ -134,7 +146,7 @@ func Post[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller fu
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
 
@@ -147,7 +159,7 @@ func Delete[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
 
@@ -160,7 +172,7 @@ func Put[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller fun
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
 
@@ -173,7 +185,7 @@ func Patch[T, B any, Contexted ctx[B]](s *RouterGroup, path string, controller f
 	var req B
 	var res T
 
-	r = r.WithRequest(req).WithResponse(res)
+	r = r.WithRequest(req).WithResponse(res).WithParams(paramsOf[Contexted]())
 	return Register(s, r, FuegoHandler(s.server, controller), middlewares...)
 }
 
@@ -224,7 +236,19 @@ func Register(group *RouterGroup, route Route, controller gin.HandlerFunc, middl
 	route.Group = group
 	route.Operation = openapi3.NewOperation()
 
-	handlers := append([]gin.HandlerFunc{controller}, middlewares...)
+	// Keep track of the route, to finalize it before serving or exporting the spec.
+	// The handlers read the up to date registry entry when serving requests.
+	route.entry = new(Route)
+
+	// Route middlewares run before the controller.
+	handlers := append([]gin.HandlerFunc{routePrelude(route.entry)}, middlewares...)
+	if group.server.requestValidation {
+		handlers = append(handlers, group.server.validateRequest)
+	}
+	if group.server.responseValidation {
+		handlers = append(handlers, group.server.validateResponse)
+	}
+	handlers = append(handlers, controller)
 
 	if route.All || route.Method == "" {
 		group.rg.Any(route.Path, handlers...)
@@ -234,7 +258,10 @@ func Register(group *RouterGroup, route Route, controller gin.HandlerFunc, middl
 
 	basePath, _ := url.Parse(group.BasePath())
 	basePath = basePath.JoinPath(route.Path)
-	route.Path = basePath.String()
+	route.Path = basePath.Path
+
+	*route.entry = route
+	group.server.routes = append(group.server.routes, route.entry)
 
 	return route
 }
@@ -246,3 +273,136 @@ func Use(s *RouterGroup, middlewares ...gin.HandlerFunc) {
 func (group *RouterGroup) Use(middlewares ...gin.HandlerFunc) {
 	group.rg.Use(middlewares...)
 }
+
+// UseStd registers standard net/http middlewares on the group, like [Security.TokenToContext] or [AuthWall].
+// For example:
+//
+//	fuego.UseStd(adminRoutes, s.Security.TokenToContext(fuego.TokenFromHeader), fuego.AuthWall("admin"))
+//
+// To use a standard middleware on a single route, see [WrapMiddleware].
+func UseStd(s *RouterGroup, middlewares ...func(http.Handler) http.Handler) {
+	s.UseStd(middlewares...)
+}
+
+func (group *RouterGroup) UseStd(middlewares ...func(http.Handler) http.Handler) {
+	for _, middleware := range middlewares {
+		group.rg.Use(WrapMiddleware(middleware))
+	}
+}
+
+// WrapMiddleware converts a standard net/http middleware into a gin middleware.
+// The request given by the middleware to the next handler (with its context values, like the JWT claims)
+// is the one seen by the next handlers and the controller, through [ContextNoBody.Req].
+// If the middleware does not call the next handler, the chain is aborted.
+// For example:
+//
+//	fuego.Get(s.RouterGroup(), "/me", myController, fuego.WrapMiddleware(fuego.AuthWall("user")))
+func WrapMiddleware(middleware func(http.Handler) http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		calledNext := false
+		ginWriter := c.Writer
+
+		middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calledNext = true
+			c.Request = r
+			var stdWriter *stdResponseWriter
+			if wrapsWriter(w, ginWriter) {
+				// The middleware wraps the response writer (cache, compression...)
+				stdWriter = &stdResponseWriter{ResponseWriter: ginWriter, w: w, size: -1}
+				c.Writer = stdWriter
+			}
+
+			c.Next()
+
+			if stdWriter != nil && stdWriter.status != 0 {
+				// Status set without body, like a 204.
+				stdWriter.WriteHeaderNow()
+			}
+			c.Writer = ginWriter
+		})).ServeHTTP(ginWriter, c.Request)
+
+		if !calledNext {
+			c.Abort()
+		}
+	}
+}
+
+// wrapsWriter reports whether the response writer given by a standard middleware to the next handler
+// is another one than the gin response writer. The writers are not compared with ==,
+// which panics for the values of non-comparable types (ex: structs with a map field).
+// A value of the same type as the gin writer is considered as wrapping it: writing to it is equivalent.
+func wrapsWriter(w http.ResponseWriter, ginWriter gin.ResponseWriter) bool {
+	if reflect.TypeOf(w) != reflect.TypeOf(ginWriter) {
+		return true
+	}
+	value := reflect.ValueOf(w)
+	if value.Kind() != reflect.Pointer {
+		return true
+	}
+	return value.Pointer() != reflect.ValueOf(ginWriter).Pointer()
+}
+
+// stdResponseWriter is a [gin.ResponseWriter] that writes to a response writer provided by a standard middleware.
+// The status, size and flushes are delegated to the wrapped writer when it supports them,
+// so the streamed responses (SSE, sequences) work behind the wrapping middlewares.
+type stdResponseWriter struct {
+	gin.ResponseWriter
+	w      http.ResponseWriter
+	status int
+	size   int // -1 until the header is written
+}
+
+func (w *stdResponseWriter) Header() http.Header { return w.w.Header() }
+
+func (w *stdResponseWriter) WriteHeader(code int) {
+	if code > 0 && w.size == -1 {
+		w.status = code
+	}
+}
+
+func (w *stdResponseWriter) WriteHeaderNow() {
+	if w.size == -1 {
+		w.size = 0
+		w.w.WriteHeader(w.Status())
+	}
+}
+
+func (w *stdResponseWriter) Write(data []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.w.Write(data)
+	w.size += n
+	return n, err
+}
+
+func (w *stdResponseWriter) WriteString(s string) (int, error) { return w.Write([]byte(s)) }
+
+func (w *stdResponseWriter) Status() int {
+	if status, ok := w.w.(interface{ Status() int }); ok && w.size != -1 {
+		return status.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *stdResponseWriter) Size() int {
+	if size, ok := w.w.(interface{ Size() int }); ok {
+		return size.Size()
+	}
+	return w.size
+}
+
+func (w *stdResponseWriter) Written() bool { return w.size != -1 }
+
+func (w *stdResponseWriter) Flush() {
+	w.WriteHeaderNow()
+	_ = http.NewResponseController(w.w).Flush()
+}
+
+func (w *stdResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.w).Hijack()
+}
+
+// Unwrap returns the wrapped response writer, for [http.ResponseController].
+func (w *stdResponseWriter) Unwrap() http.ResponseWriter { return w.w }
@@ -0,0 +1,188 @@
+package fuego
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKeyTest string
+
+func TestRegisterMiddlewaresOrder(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	var order []string
+	Get(s.RouterGroup(), "/order", func(c ContextNoBody) (string, error) {
+		order = append(order, "controller")
+		return "ok", nil
+	}, func(c *gin.Context) {
+		order = append(order, "first")
+	}, func(c *gin.Context) {
+		order = append(order, "second")
+	})
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/order", nil)
+	s.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, []string{"first", "second", "controller"}, order)
+}
+
+type mapResponseWriter struct {
+	http.ResponseWriter
+	tags map[string]string
+}
+
+func (w mapResponseWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestWrapMiddleware(t *testing.T) {
+	setContextValue := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Std", "std")
+			ctx := context.WithValue(r.Context(), ctxKeyTest("key"), "value")
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+
+	blockEverything := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+	}
+
+	uppercaseBody := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			w.WriteHeader(rec.Code)
+			_, _ = w.Write([]byte(strings.ToUpper(rec.Body.String())))
+		})
+	}
+
+	// Non-comparable response writer, passed by value.
+	taggedWriter := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(mapResponseWriter{ResponseWriter: w, tags: map[string]string{}}, r)
+		})
+	}
+
+	s := NewServer(WithoutLogger())
+
+	controller := func(c ContextNoBody) (string, error) {
+		value, _ := c.Req.Context().Value(ctxKeyTest("key")).(string)
+		return "value=" + value, nil
+	}
+	Get(s.RouterGroup(), "/tagged", controller, WrapMiddleware(taggedWriter), WrapMiddleware(setContextValue))
+	Get(s.RouterGroup(), "/tagged/events", func(c ContextNoBody) (EventStream[int], error) {
+		return Events(slices.Values([]int{1, 2})), nil
+	}, WrapMiddleware(taggedWriter))
+	Post(s.RouterGroup(), "/tagged/created", func(c ContextNoBody) (any, error) {
+		c.SetStatus(http.StatusNoContent)
+		return nil, nil
+	}, WrapMiddleware(taggedWriter))
+
+	group := Group(s.RouterGroup(), "/group")
+	UseStd(group, setContextValue)
+	Get(group, "/context", controller)
+	Get(s.RouterGroup(), "/route", controller, WrapMiddleware(setContextValue))
+	Get(s.RouterGroup(), "/blocked", controller, WrapMiddleware(blockEverything))
+	Get(s.RouterGroup(), "/uppercase", controller, WrapMiddleware(uppercaseBody))
+
+	t.Run("context changes are visible to the controller", func(t *testing.T) {
+		for _, path := range []string{"/group/context", "/route"} {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, path, nil)
+			s.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code, path)
+			require.Equal(t, "std", w.Header().Get("X-Std"), path)
+			require.Contains(t, w.Body.String(), "value=value", path)
+		}
+	})
+
+	t.Run("middleware not calling next aborts", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/blocked", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusTeapot, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("middleware passing a non-comparable response writer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tagged", nil)
+		require.NotPanics(t, func() { s.ServeHTTP(w, r) })
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "value=value")
+	})
+
+	t.Run("streams and statuses behind a wrapping middleware", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/tagged/events", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.True(t, w.Flushed)
+		require.Equal(t, "data: 1\n\ndata: 2\n\n", w.Body.String())
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodPost, "/tagged/created", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+	})
+
+	t.Run("middleware wrapping the response writer", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/uppercase", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "VALUE=")
+	})
+}
+
+func TestUseStdSecurity(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	authenticated := Group(s.RouterGroup(), "/authenticated")
+	UseStd(authenticated, s.Security.TokenToContext(TokenFromHeader))
+	Get(authenticated, "/me", func(c ContextNoBody) (string, error) {
+		claims, err := TokenFromContext(c.Context())
+		if err != nil {
+			return "", err
+		}
+		return claims.GetSubject()
+	})
+
+	t.Run("unauthenticated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/authenticated/me", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+	})
+
+	t.Run("claims are visible to the controller", func(t *testing.T) {
+		token, err := s.Security.GenerateToken(jwt.MapClaims{"sub": "user-id"})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/authenticated/me", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "user-id")
+	})
+}
@@ -0,0 +1,238 @@
+package fuego
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// mediaRange is a media range of the Accept header, with its quality value (RFC 9110 section 12.5.1).
+type mediaRange struct {
+	typ, subtype string
+	quality      float64
+}
+
+// parseAccept parses the media ranges of the Accept header.
+// Invalid quality values are considered as 1, as browsers do.
+func parseAccept(accept string) []mediaRange {
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				q, err := strconv.ParseFloat(value, 64)
+				if err == nil && q >= 0 && q <= 1 {
+					quality = q
+				}
+			}
+		}
+
+		ranges = append(ranges, mediaRange{typ: typ, subtype: subtype, quality: quality})
+	}
+	return ranges
+}
+
+// match returns the specificity of the range for the media type:
+// 3 for an exact match, 2 for type/*, 1 for */*, 0 if it does not match.
+func (m mediaRange) match(mediaType string) int {
+	typ, subtype, _ := strings.Cut(mediaType, "/")
+	switch {
+	case m.typ == typ && m.subtype == subtype:
+		return 3
+	case m.typ == typ && m.subtype == "*":
+		return 2
+	case m.typ == "*" && m.subtype == "*":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// negotiate returns the offered media types acceptable for the Accept header, by order of preference:
+// highest quality value first, then most specific matching media range, then order of the offers.
+// The quality of an offer is the one of the most specific media range matching it.
+// If the Accept header is empty, all the offers are acceptable.
+func negotiate(accept string, offers []string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return offers
+	}
+
+	type candidate struct {
+		mediaType   string
+		quality     float64
+		specificity int
+		order       int
+	}
+
+	ranges := parseAccept(accept)
+	candidates := []candidate{}
+	for i, offer := range offers {
+		best := candidate{mediaType: offer, order: i}
+		for _, r := range ranges {
+			specificity := r.match(offer)
+			if specificity > best.specificity {
+				best.specificity = specificity
+				best.quality = r.quality
+			}
+		}
+
+		if best.specificity > 0 && best.quality > 0 {
+			candidates = append(candidates, best)
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.quality != b.quality:
+			if a.quality > b.quality {
+				return -1
+			}
+			return 1
+		case a.specificity != b.specificity:
+			return b.specificity - a.specificity
+		default:
+			return a.order - b.order
+		}
+	})
+
+	acceptable := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		acceptable = append(acceptable, c.mediaType)
+	}
+	return acceptable
+}
+
+// responseOffers returns the media types the response can be encoded to, by order of preference.
+// If the route declares the content types of its response with [Route.WithResponse], only those are offered.
+// Otherwise, the type inferred from the response comes first, followed by the ones of the codecs.
+func responseOffers(r *http.Request, codecs codecRegistry, ans any) []string {
+	t := reflect.TypeOf(ans)
+
+	if route := routeFromRequest(r); route != nil {
+		offers := []string{}
+		for _, contentType := range route.Response.ContentType {
+			if codecs.encoder(contentType, t) != nil {
+				offers = appendMissing(offers, contentType)
+			}
+		}
+		if len(offers) > 0 {
+			return offers
+		}
+	}
+
+	offers := []string{}
+	if inferred := InferAcceptHeaderFromType(ans); codecs.encoder(inferred, t) != nil {
+		offers = append(offers, inferred)
+	}
+	return appendMissing(offers, codecs.encodableMediaTypes(t)...)
+}
+
+// errorOffers returns the media types errors can be encoded to, by order of preference,
+// each followed by its problem details media type if any.
+// If the route declares the content types of its response, only those are offered, with JSON as a last resort.
+func errorOffers(r *http.Request, codecs codecRegistry) []string {
+	offers := []string{}
+	if route := routeFromRequest(r); route != nil {
+		for _, contentType := range route.Response.ContentType {
+			if codecs.encoder(contentType, errorType) != nil {
+				offers = appendMissing(offers, contentType)
+			}
+		}
+	}
+
+	if len(offers) == 0 {
+		offers = codecs.encodableMediaTypes(errorType)
+	} else {
+		offers = appendMissing(offers, "application/json")
+	}
+
+	withProblems := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		withProblems = appendMissing(withProblems, offer)
+		for problem, base := range problemMediaTypes {
+			if base == offer {
+				withProblems = appendMissing(withProblems, problem)
+			}
+		}
+	}
+	return withProblems
+}
+
+// errorEncodingMediaType returns the media type of the codec encoding the errors negotiated to the media type.
+func errorEncodingMediaType(mediaType string) string {
+	if base, ok := problemMediaTypes[mediaType]; ok {
+		return base
+	}
+	return mediaType
+}
+
+// addVary adds the header name to the Vary header, if not already there.
+func addVary(header http.Header, name string) {
+	for _, value := range header.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(existing), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
+
+// routeContextKey is the key of the route handling the request in the request context.
+const routeContextKey contextKeyType = "fuego_route"
+
+// serverContextKey is the key of the server handling the request in the request context.
+const serverContextKey contextKeyType = "fuego_server"
+
+// routePrelude is the first handler of the routes. It stores the route in the request context,
+// so the next handlers and the serializers can read its up to date declaration.
+func routePrelude(route *Route) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), routeContextKey, route))
+	}
+}
+
+// serverPrelude is the first handler of the server. It stores the server in the request context,
+// so the standard middlewares can answer with its error handler, see [HandleError].
+// Once the request is handled, it removes the temporary files of the multipart form parsed on the copies of the request.
+func (s *Server) serverPrelude(c *gin.Context) {
+	c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), serverContextKey, s))
+	defer func() { removeMultipartForm(c.Request) }()
+
+	c.Next()
+}
+
+// serverFromRequest returns the server handling the request, or nil if it is not handled by a Fuego server.
+func serverFromRequest(r *http.Request) *Server {
+	if r == nil {
+		return nil
+	}
+	if s, ok := r.Context().Value(serverContextKey).(*Server); ok {
+		return s
+	}
+	if route := routeFromRequest(r); route != nil {
+		return route.mainRouter
+	}
+	return nil
+}
+
+// routeFromRequest returns the route handling the request, or nil if it is not a Fuego route.
+func routeFromRequest(r *http.Request) *Route {
+	if r == nil {
+		return nil
+	}
+	route, _ := r.Context().Value(routeContextKey).(*Route)
+	return route
+}
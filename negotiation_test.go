@@ -0,0 +1,154 @@
+package fuego
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "application/xml", "text/plain"}
+
+	tests := []struct {
+		accept   string
+		expected []string
+	}{
+		{accept: "", expected: offers},
+		{accept: "*/*", expected: offers},
+		{accept: "application/json;q=0.1, application/xml", expected: []string{"application/xml", "application/json"}},
+		{accept: "application/*", expected: []string{"application/json", "application/xml"}},
+		{accept: "text/*;q=0.9, application/xml;q=0.5, */*;q=0.1", expected: []string{"text/plain", "application/xml", "application/json"}},
+		{accept: "*/*;q=0.5, application/json", expected: []string{"application/json", "application/xml", "text/plain"}},
+		{accept: "application/*;q=0.8, application/json;q=0", expected: []string{"application/xml"}},
+		{accept: "APPLICATION/XML ; Q=1", expected: []string{"application/xml"}},
+		{accept: "image/png", expected: []string{}},
+		{accept: "invalid, text/plain;q=abc", expected: []string{"text/plain"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.accept, func(t *testing.T) {
+			require.Equal(t, tc.expected, negotiate(tc.accept, offers))
+		})
+	}
+}
+
+func TestSendNegotiation(t *testing.T) {
+	s := NewServer(WithoutLogger(), WithContentNegotiation())
+
+	Get(s.RouterGroup(), "/any", func(c ContextNoBody) (MyStruct, error) {
+		return MyStruct{B: "any"}, nil
+	})
+
+	Get(s.RouterGroup(), "/xml-only", func(c ContextNoBody) (MyStruct, error) {
+		return MyStruct{B: "xml"}, nil
+	}).WithResponse(MyStruct{}, "application/xml")
+
+	Get(s.RouterGroup(), "/xml-only-error", func(c ContextNoBody) (MyStruct, error) {
+		return MyStruct{}, NotFoundError{Title: "Not Found", Err: http.ErrNoLocation}
+	}).WithResponse(MyStruct{}, "application/xml")
+
+	send := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("prefers the highest quality", func(t *testing.T) {
+		w := send("/any", "application/json;q=0.1, application/xml")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+	})
+
+	t.Run("wildcard subtype", func(t *testing.T) {
+		w := send("/any", "application/*")
+
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("declared content types restrict the negotiation", func(t *testing.T) {
+		w := send("/xml-only", "")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/xml", w.Header().Get("Content-Type"))
+
+		w = send("/xml-only", "application/json")
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "Not Acceptable")
+	})
+
+	t.Run("nothing acceptable", func(t *testing.T) {
+		w := send("/any", "image/png")
+
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+		require.Equal(t, "Accept", w.Header().Get("Vary"))
+	})
+
+	t.Run("errors follow the declared content types", func(t *testing.T) {
+		w := send("/xml-only-error", "")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("errors fall back to JSON", func(t *testing.T) {
+		w := send("/xml-only-error", "image/png")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("problem details media types are only offered for errors", func(t *testing.T) {
+		w := send("/any", "application/problem+xml")
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+		require.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"))
+
+		w = send("/xml-only-error", "application/problem+xml")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"))
+	})
+}
+
+func TestDefaultSerializer(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Get(s.RouterGroup(), "/greeting", func(c ContextNoBody) (string, error) {
+		return "hello", nil
+	})
+
+	Get(s.RouterGroup(), "/missing", func(c ContextNoBody) (string, error) {
+		return "", NotFoundError{Title: "Not Found"}
+	})
+
+	send := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("sends JSON without negotiating", func(t *testing.T) {
+		w := send("/greeting", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Equal(t, `"hello"`+"\n", w.Body.String())
+
+		w = send("/missing", "application/xml")
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("documents JSON only", func(t *testing.T) {
+		content := s.OpenApiSpec.Paths.Find("/greeting").Get.Responses.Status(http.StatusOK).Value.Content
+		require.Len(t, content, 1)
+		require.NotNil(t, content.Get("application/json"))
+	})
+}
@@ -3,13 +3,20 @@ package fuego
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
 )
 
 func NewOpenApiSpec() openapi3.T {
@@ -51,19 +58,103 @@ func (s *RouterGroup) RouteConfig(cfg func(Route) Route) {
 	s.routeCfg = append(s.routeCfg, cfg)
 }
 
-// OutputOpenAPISpec takes the OpenAPI spec and outputs it to a JSON file and/or serves it on a URL.
-// Also serves a Swagger UI.
+// OutputOpenAPISpec takes the OpenAPI spec and serves it on a URL, with a Swagger UI.
+// The spec is also saved to a JSON file when the server runs, see [Server.Run].
 // To modify its behavior, use the [WithOpenAPIConfig] option.
 func (s *Server) OutputOpenAPISpec() openapi3.T {
+	s.finalizeRoutes()
+	s.documentProblemTypes()
+
 	// Validate
 	err := s.OpenApiSpec.Validate(context.Background())
 	if err != nil {
 		slog.Error("Error validating spec", "error", err)
 	}
 
+	// Marshal spec to JSON
+	jsonSpec, err := s.MarshalSpec(s.OpenAPIConfig.PrettyFormatJson)
+	if err != nil {
+		slog.Error("Error marshaling spec to JSON", "error", err)
+		return s.OpenApiSpec
+	}
+	s.openAPISpecJSON = jsonSpec
+
+	if !s.OpenAPIConfig.DisableSwagger {
+		s.openAPIRoutesOnce.Do(s.registerOpenAPIRoutes)
+	}
+
 	return s.OpenApiSpec
 }
 
+// saveOpenAPISpec saves the last marshaled OpenAPI spec to [OpenAPIConfig.JsonFilePath], unless disabled.
+// It is only called when the server runs, so the servers used in tests through [Server.ServeHTTP] write nothing.
+func (s *Server) saveOpenAPISpec() {
+	if s.OpenAPIConfig.DisableLocalSave || s.openAPISpecJSON == nil {
+		return
+	}
+
+	err := s.saveOpenAPIToFile(s.OpenAPIConfig.JsonFilePath, s.openAPISpecJSON)
+	if err != nil {
+		slog.Error("Error saving spec to local path", "error", err, "path", s.OpenAPIConfig.JsonFilePath)
+	}
+}
+
+func (s *Server) saveOpenAPIToFile(jsonSpecLocalPath string, jsonSpec []byte) error {
+	jsonFolder := filepath.Dir(jsonSpecLocalPath)
+
+	err := os.MkdirAll(jsonFolder, 0o750)
+	if err != nil {
+		return fmt.Errorf("error creating docs directory: %w", err)
+	}
+
+	err = os.WriteFile(jsonSpecLocalPath, jsonSpec, 0o600)
+	if err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+
+	s.printOpenAPIMessage("JSON file: " + jsonSpecLocalPath)
+	return nil
+}
+
+// registerOpenAPIRoutes registers the routes serving the OpenAPI spec and UI.
+// They are hidden from the spec itself.
+func (s *Server) registerOpenAPIRoutes() {
+	openAPIGroup := s.rg.Group("", WithoutTag()).Hide()
+
+	GetGin(openAPIGroup, s.OpenAPIConfig.JsonUrl, func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", s.openAPISpecJSON)
+	})
+	s.printOpenAPIMessage("JSON spec: " + s.OpenAPIConfig.JsonUrl)
+
+	if s.OpenAPIConfig.DisableSwaggerUI || s.OpenAPIConfig.UIHandler == nil {
+		return
+	}
+
+	uiHandler := gin.WrapH(s.OpenAPIConfig.UIHandler(s.OpenAPIConfig.JsonUrl))
+	GetGin(openAPIGroup, s.OpenAPIConfig.SwaggerUrl, uiHandler)
+	GetGin(openAPIGroup, s.OpenAPIConfig.SwaggerUrl+"/index.html", uiHandler)
+	s.printOpenAPIMessage("OpenAPI UI: " + s.OpenAPIConfig.SwaggerUrl + "/index.html")
+}
+
+func (s *Server) printOpenAPIMessage(msg string) {
+	if !s.disableStartupMessages {
+		slog.Info(msg)
+	}
+}
+
+var (
+	jsonSpecUrlRegex = regexp.MustCompile(`^/[\w./-]*\.json$`)
+	swaggerUrlRegex  = regexp.MustCompile(`^/[\w./-]*[\w-]$`)
+)
+
+func validateJsonSpecUrl(jsonSpecUrl string) bool {
+	return jsonSpecUrlRegex.MatchString(jsonSpecUrl)
+}
+
+func validateSwaggerUrl(swaggerUrl string) bool {
+	return swaggerUrlRegex.MatchString(swaggerUrl)
+}
+
 func (s *Server) MarshalSpec(prettyFormatJSON bool) ([]byte, error) {
 	if prettyFormatJSON {
 		return json.MarshalIndent(s.OpenApiSpec, "", "	")
@@ -90,11 +181,21 @@ func RegisterOpenAPIOperation(group *RouterGroup, route Route) (*openapi3.Operat
 		route.Param(param.Type, param.Name, param.Description, param.opts...)
 	}
 
+	// Typed parameters
+	if route.Params != nil {
+		for _, parameter := range group.server.parametersFromStruct(reflect.TypeOf(route.Params)) {
+			route.Operation.AddParameter(parameter.Value)
+		}
+	}
+
 	// Request Body
 	if route.Operation.RequestBody == nil && route.Request.Type != nil {
 		bodyTag := schemaTagFromType(group.server, route.Request.Type)
 
 		if bodyTag.name != "unknown-interface" {
+			if len(route.Request.ContentType) == 0 {
+				route.Request.ContentType = group.server.codecs.documentedRequestTypes(reflect.TypeOf(route.Request.Type))
+			}
 			requestBody := newRequestBody(bodyTag, route.Request)
 			group.server.OpenApiSpec.Components.RequestBodies[bodyTag.name] = &openapi3.RequestBodyRef{
 				Value: requestBody,
@@ -118,9 +219,32 @@ func RegisterOpenAPIOperation(group *RouterGroup, route Route) (*openapi3.Operat
 		addResponse(group.server, route.Operation, openAPIErrors.Code, openAPIErrors.Schema)
 	}
 
-	// Response - 200
+	// Response - success
+	successStatus := route.successStatus()
 	if route.Response.Type != nil {
-		addResponse(group.server, route.Operation, 200, route.Response)
+		if len(route.Response.ContentType) == 0 && route.Response.sequence != nil {
+			route.Response.ContentType = slices.Clone(sequenceMediaTypes)
+			if group.server.contentNegotiation {
+				route.Response.ContentType = appendMissing(route.Response.ContentType, group.server.codecs.sequenceMediaTypes(route.Response.sequence, true)...)
+			}
+		} else if len(route.Response.ContentType) == 0 && group.server.contentNegotiation {
+			route.Response.ContentType = group.server.codecs.documentedResponseTypes(reflect.TypeOf(route.Response.Type))
+		}
+		addResponse(group.server, route.Operation, successStatus, route.Response)
+	} else if route.DefaultStatusCode != 0 {
+		route.Operation.AddResponse(successStatus, openapi3.NewResponse().WithDescription(route.Response.Description))
+	}
+
+	if response := route.Operation.Responses.Status(successStatus); response != nil && response.Value != nil {
+		if response.Value.Description == nil || *response.Value.Description == "" {
+			response.Value.WithDescription(http.StatusText(successStatus))
+		}
+		for name, header := range route.ResponseHeaders {
+			if response.Value.Headers == nil {
+				response.Value.Headers = openapi3.Headers{}
+			}
+			response.Value.Headers[name] = header
+		}
 	}
 
 	for _, pathParam := range parseGinPathParams(route.Path) {
@@ -129,6 +253,11 @@ func RegisterOpenAPIOperation(group *RouterGroup, route Route) (*openapi3.Operat
 			continue
 		}
 
+		// Already documented with its type by the params struct
+		if route.Operation.Parameters.GetByInAndName(openapi3.ParameterInPath, strings.TrimPrefix(pathParam, "*")) != nil {
+			continue
+		}
+
 		parameter := openapi3.NewPathParameter(pathParam)
 
 		parameter.Schema = openapi3.NewStringSchema().NewRef()
@@ -148,7 +277,11 @@ func addResponse(s *Server, operation *openapi3.Operation, code int, schema Sche
 	responseSchema := schemaTagFromType(s, schema.Type)
 
 	// add default type to content type
-	content := openapi3.NewContentWithSchemaRef(&responseSchema.SchemaRef, schema.ContentType)
+	contentType := schema.ContentType
+	if len(contentType) == 0 {
+		contentType = []string{"application/json"}
+	}
+	content := openapi3.NewContentWithSchemaRef(&responseSchema.SchemaRef, contentType)
 
 	response := openapi3.NewResponse().
 		WithDescription(schema.Description).
@@ -184,6 +317,15 @@ func schemaTagFromType(s *Server, v any) schemaTag {
 		}
 	}
 
+	if _, ok := v.(binaryContent); ok {
+		return schemaTag{
+			name: "binary",
+			SchemaRef: openapi3.SchemaRef{
+				Value: openapi3.NewStringSchema().WithFormat("binary"),
+			},
+		}
+	}
+
 	return dive(s, reflect.TypeOf(v), schemaTag{}, 5)
 }
 
@@ -242,6 +384,11 @@ func getName(t reflect.Type) string {
 		return t.Name()
 	}
 
+	// Predeclared types (string, int, etc.) keep their name.
+	if t.PkgPath() == "" && name == t.Name() {
+		return name
+	}
+
 	if len(name) == 0 || name[len(name)-1] == ']' {
 		generic := ""
 		name, generic, _ = strings.Cut(name[:len(name)-1], "[")
@@ -263,6 +410,18 @@ func getName(t reflect.Type) string {
 	return builder.String()
 }
 
+// fileHeaderType is the type of the uploaded files, see [readMultipart].
+var fileHeaderType = reflect.TypeFor[multipart.FileHeader]()
+
+// customizeSchema adapts the schemas generated by openapi3gen for the types handled specifically by Fuego:
+// uploaded files are documented as binary strings.
+func customizeSchema(_ string, t reflect.Type, _ reflect.StructTag, schema *openapi3.Schema) error {
+	if t == fileHeaderType {
+		*schema = *openapi3.NewStringSchema().WithFormat("binary")
+	}
+	return nil
+}
+
 // getOrCreateSchema is used to get a schema from the OpenAPI spec.
 // If the schema does not exist, it will create a new schema and add it to the OpenAPI spec.
 func (s *Server) getOrCreateSchema(key string, v any) *openapi3.Schema {
@@ -294,46 +453,69 @@ func (s *Server) createSchema(key string, v any) *openapi3.SchemaRef {
 }
 
 // parseStructTags parses struct tags and modifies the schema accordingly.
-// t must be a struct type.
+// The fields of the nested and embedded structs, and of the items of the slices and maps, are parsed too.
 // It adds the following struct tags (tag => OpenAPI schema field):
 // - description => description
 // - example => example
 // - json => nullable (if contains omitempty)
 // - validate:
 //   - required => required
-//   - min=1 => min=1 (for integers)
-//   - min=1 => minLength=1 (for strings)
-//   - max=100 => max=100 (for integers)
-//   - max=100 => maxLength=100 (for strings)
+//   - min, max, gt, gte, lt, lte, len => minimum, maximum, exclusiveMinimum, exclusiveMaximum (for numbers)
+//   - min, max, gt, gte, lt, lte, len => minLength, maxLength (for strings)
+//   - min, max, gt, gte, lt, lte, len => minItems, maxItems (for slices), minProperties, maxProperties (for maps)
+//   - oneof=a b => enum
+//   - email, uuid, url, ip, ipv4, ipv6, hostname, datetime=2006-01-02... => format
+//   - alpha, alphanum, numeric, e164, startswith=a... => pattern
+//   - dive => the following tags apply to the items of the slices and the values of the maps
+//   - custom tags => see [ValidationRule.Schema]
 func (s *Server) parseStructTags(t reflect.Type, schemaRef *openapi3.SchemaRef) {
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	if schemaRef == nil || schemaRef.Ref != "" || schemaRef.Value == nil {
+		return
 	}
+	t = indirectType(t)
 
-	if t.Kind() != reflect.Struct {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if elem := elementSchema(t, schemaRef.Value); elem != nil {
+			s.parseStructTags(t.Elem(), &openapi3.SchemaRef{Value: elem})
+		}
+		return
+	case reflect.Struct:
+		if schemaRef.Value.Properties == nil {
+			return
+		}
+	default:
 		return
 	}
 
 	for i := range t.NumField() {
 		field := t.Field(i)
-		if field.Anonymous {
+		if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); field.Anonymous && name == "" {
 			s.parseStructTags(field.Type, schemaRef)
 			continue
 		}
+		if !field.IsExported() {
+			continue
+		}
 
-		parseStructTagFields(field, schemaRef)
+		s.parseStructTagFields(field, schemaRef)
 	}
 }
 
-func parseStructTagFields(field reflect.StructField, schemaRef *openapi3.SchemaRef) {
-	jsonFieldName := field.Tag.Get("json")
-	jsonFieldName = strings.Split(jsonFieldName, ",")[0] // remove omitempty, etc
+// jsonFieldName returns the name of the field in the JSON schemas, or "-" if it is ignored.
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",") // remove omitempty, etc
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func (s *Server) parseStructTagFields(field reflect.StructField, schemaRef *openapi3.SchemaRef) {
+	jsonFieldName := jsonFieldName(field)
 	if jsonFieldName == "-" {
 		return
 	}
-	if jsonFieldName == "" {
-		jsonFieldName = field.Name
-	}
 
 	property := schemaRef.Value.Properties[jsonFieldName]
 	if property == nil {
@@ -342,6 +524,9 @@ func parseStructTagFields(field reflect.StructField, schemaRef *openapi3.SchemaR
 	}
 
 	propertyCopy := *property
+	if propertyCopy.Value == nil {
+		return
+	}
 	propertyValue := *propertyCopy.Value
 
 	// Example
@@ -360,37 +545,11 @@ func parseStructTagFields(field reflect.StructField, schemaRef *openapi3.SchemaR
 	// Validation
 	validateTag, ok := field.Tag.Lookup("validate")
 	validateTags := strings.Split(validateTag, ",")
-	if ok && slices.Contains(validateTags, "required") {
+	if ok && slices.Contains(validateTags, "required") && !slices.Contains(schemaRef.Value.Required, jsonFieldName) {
 		schemaRef.Value.Required = append(schemaRef.Value.Required, jsonFieldName)
 	}
-	for _, validateTag := range validateTags {
-		if strings.HasPrefix(validateTag, "min=") {
-			min, err := strconv.Atoi(strings.Split(validateTag, "=")[1])
-			if err != nil {
-				slog.Warn("Min might be incorrect (should be integer)", "error", err)
-			}
-
-			if propertyValue.Type.Is(openapi3.TypeInteger) {
-				minPtr := float64(min)
-				propertyValue.Min = &minPtr
-			} else if propertyValue.Type.Is(openapi3.TypeString) {
-				propertyValue.MinLength = uint64(min)
-			}
-		}
-
-		if strings.HasPrefix(validateTag, "max=") {
-			max, err := strconv.Atoi(strings.Split(validateTag, "=")[1])
-			if err != nil {
-				slog.Warn("Max might be incorrect (should be integer)", "error", err)
-			}
-			if propertyValue.Type.Is(openapi3.TypeInteger) {
-				maxPtr := float64(max)
-				propertyValue.Max = &maxPtr
-			} else if propertyValue.Type.Is(openapi3.TypeString) {
-				maxPtr := uint64(max)
-				propertyValue.MaxLength = &maxPtr
-			}
-		}
+	if ok && propertyCopy.Ref == "" {
+		s.documentValidateTags(field.Type, &propertyValue, validateTags)
 	}
 
 	// Description
@@ -407,6 +566,9 @@ func parseStructTagFields(field reflect.StructField, schemaRef *openapi3.SchemaR
 
 	propertyCopy.Value = &propertyValue
 	schemaRef.Value.Properties[jsonFieldName] = &propertyCopy
+
+	// Nested structs
+	s.parseStructTags(field.Type, &propertyCopy)
 }
 
 type OpenAPIDescriptioner interface {
@@ -0,0 +1,413 @@
+package fuego
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gin-gonic/gin"
+)
+
+// validateRequest is the last handler before the controllers when [WithRequestValidation] is set.
+// It validates the parameters, the content type and the body of the request against the OpenAPI operation of the route,
+// and answers the invalid requests with a [BadRequestError] listing the invalid fields.
+// The bodies in media types without kin-openapi decoder (ex: XML) and the forms are left to the validation of the controllers,
+// and the security requirements to the authentication middlewares.
+func (s *Server) validateRequest(c *gin.Context) {
+	route := routeFromRequest(c.Request)
+	if route == nil || route.Operation == nil {
+		return
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:          true,
+		SkipSettingDefaults: true,
+		AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+	}
+	mediaType, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	if openapi3filter.RegisteredBodyDecoder(mediaType) == nil || isFormMediaType(mediaType) {
+		options.ExcludeRequestBody = true
+	} else if c.Request.Body != nil {
+		// The body is read in memory to be validated, then read again by the controller.
+		limit := s.maxBodySize
+		if limit == 0 {
+			limit = maxBodySize
+		}
+		c.Request.Body = http.MaxBytesReader(nil, c.Request.Body, limit)
+	}
+
+	input := s.requestValidationInput(c, route)
+	input.Options = options
+	err := openapi3filter.ValidateRequest(c.Request.Context(), input)
+	if err != nil {
+		c.Abort()
+		s.serializeError(c.Writer, c.Request, requestValidationError(err))
+	}
+}
+
+// isFormMediaType reports whether the media type is a form, read in memory by the openapi3filter body decoders.
+// The forms are streamed by the controllers instead, within the file size limits of [WithMaxFileSize].
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "multipart/form-data" || mediaType == "application/x-www-form-urlencoded"
+}
+
+// requestValidationInput returns the input of the openapi3filter validations of the request, for the OpenAPI operation of the route.
+func (s *Server) requestValidationInput(c *gin.Context, route *Route) *openapi3filter.RequestValidationInput {
+	path := convertGinPathToStdPath(route.Path)
+	pathItem := &openapi3.PathItem{}
+	if s.OpenApiSpec.Paths != nil {
+		if item := s.OpenApiSpec.Paths.Value(path); item != nil {
+			pathItem = item
+		}
+	}
+
+	pathParams := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		pathParams[param.Key] = param.Value
+	}
+
+	return &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: pathParams,
+		Route: &routers.Route{
+			Spec:      &s.OpenApiSpec,
+			Path:      path,
+			PathItem:  pathItem,
+			Method:    route.Method,
+			Operation: route.Operation,
+		},
+	}
+}
+
+// requestValidationError converts the errors of [openapi3filter.ValidateRequest] into a [BadRequestError],
+// with an [ErrorItem] by invalid parameter or body field.
+// Unexpected content types are answered with an [UnsupportedMediaTypeError], and too large bodies with a 413.
+func requestValidationError(err error) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return maxBytesError
+	}
+
+	validationError := BadRequestError{
+		Err:   err,
+		Title: "Request Validation Error",
+	}
+	var errorsSummary []string
+	for _, err := range unwrapMultiError(err) {
+		var requestError *openapi3filter.RequestError
+		if !errors.As(err, &requestError) {
+			errorsSummary = append(errorsSummary, err.Error())
+			continue
+		}
+
+		if requestError.RequestBody != nil && strings.HasPrefix(requestError.Reason, "header Content-Type has unexpected value") {
+			return UnsupportedMediaTypeError{
+				Err:    err,
+				Detail: requestError.Reason,
+			}
+		}
+
+		for _, item := range requestErrorItems(requestError) {
+			summary := item.Reason
+			if item.Name != "" {
+				summary = item.Name + ": " + summary
+			}
+			errorsSummary = append(errorsSummary, summary)
+			validationError.Errors = append(validationError.Errors, item)
+		}
+	}
+
+	validationError.Detail = strings.Join(errorsSummary, ", ")
+
+	return validationError
+}
+
+// requestErrorItems returns the items of the invalid parameter, or of the invalid fields of the body.
+func requestErrorItems(requestError *openapi3filter.RequestError) []ErrorItem {
+	if param := requestError.Parameter; param != nil {
+		reasons := make([]string, 0, 1)
+		for _, err := range unwrapMultiError(requestError.Err) {
+			reasons = append(reasons, schemaErrorReason(err))
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, requestError.Reason)
+		}
+		return []ErrorItem{{
+			Name:   param.Name,
+			Reason: strings.Join(reasons, ", "),
+			In:     param.In,
+		}}
+	}
+
+	var items []ErrorItem
+	for _, err := range unwrapMultiError(requestError.Err) {
+		var schemaError *openapi3.SchemaError
+		if !errors.As(err, &schemaError) {
+			items = append(items, ErrorItem{Reason: requestError.Error(), In: "body"})
+			continue
+		}
+		name, pointer := schemaErrorPath(schemaError)
+		items = append(items, ErrorItem{
+			Name:    name,
+			Reason:  schemaError.Reason,
+			Pointer: pointer,
+			In:      "body",
+			More: map[string]any{
+				"keyword": schemaError.SchemaField,
+			},
+		})
+	}
+	if len(items) == 0 {
+		items = append(items, ErrorItem{Reason: requestError.Error(), In: "body"})
+	}
+
+	return items
+}
+
+// schemaErrorReason returns the reason of the schema errors, without the schema and the value.
+func schemaErrorReason(err error) string {
+	var schemaError *openapi3.SchemaError
+	if errors.As(err, &schemaError) {
+		return schemaError.Reason
+	}
+	return err.Error()
+}
+
+// schemaErrorPath returns the name of the invalid field (ex: address.zip, items[0]) and its JSON Pointer (ex: /address/zip, /items/0).
+func schemaErrorPath(schemaError *openapi3.SchemaError) (string, string) {
+	var name, pointer strings.Builder
+	for _, segment := range schemaError.JSONPointer() {
+		pointer.WriteString("/" + jsonPointerEscaper.Replace(segment))
+		if isIndex(segment) {
+			name.WriteString("[" + segment + "]")
+			continue
+		}
+		if name.Len() > 0 {
+			name.WriteString(".")
+		}
+		name.WriteString(segment)
+	}
+	return name.String(), pointer.String()
+}
+
+func isIndex(segment string) bool {
+	if segment == "" {
+		return false
+	}
+	for _, r := range segment {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// unwrapMultiError returns the errors of the nested [openapi3.MultiError]s, or the error itself.
+func unwrapMultiError(err error) []error {
+	if err == nil {
+		return nil
+	}
+	multiError, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, err := range multiError {
+		errs = append(errs, unwrapMultiError(err)...)
+	}
+	return errs
+}
+
+// validateResponse is the handler wrapping the controllers when [WithResponseValidation] is set.
+// It buffers the response of the controller, validates its headers and its body for the returned status
+// against the OpenAPI operation of the route, then sends it.
+// The mismatches are logged, and answered with a 500 error in strict mode.
+// The streamed responses (flushed or hijacked) and the bodies in media types without kin-openapi decoder are not validated.
+func (s *Server) validateResponse(c *gin.Context) {
+	route := routeFromRequest(c.Request)
+	if route == nil || route.Operation == nil || route.Operation.Responses.Len() == 0 {
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	// Restored on panics too, for the recovery middleware to answer on the real response writer.
+	defer func() { c.Writer = recorder.ResponseWriter }()
+
+	c.Next()
+
+	if recorder.streamed {
+		return
+	}
+
+	header := recorder.Header()
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if mediaType == "application/problem+json" && !documentsMediaType(route.Operation, recorder.Status(), mediaType) {
+		// The errors are documented as application/json by default, and sent as problem details.
+		header = header.Clone()
+		header.Set("Content-Type", "application/json")
+	}
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: s.requestValidationInput(c, route),
+		Status:                 recorder.Status(),
+		Header:                 header,
+		Options: &openapi3filter.Options{
+			MultiError:          true,
+			ExcludeResponseBody: recorder.body.Len() == 0 || openapi3filter.RegisteredBodyDecoder(mediaType) == nil,
+		},
+	}
+	input.SetBodyBytes(recorder.body.Bytes())
+
+	err := openapi3filter.ValidateResponse(c.Request.Context(), input)
+	if err == nil {
+		recorder.send()
+		return
+	}
+
+	message := responseValidationMessage(err)
+	slog.Warn("Response does not match the OpenAPI spec", "method", c.Request.Method, "path", c.Request.URL.Path, "status", recorder.Status(), "error", message)
+	if !s.strictResponses {
+		recorder.send()
+		return
+	}
+
+	recorder.Header().Del("Content-Length")
+	s.serializeError(recorder.ResponseWriter, c.Request, HTTPError{
+		Err:    err,
+		Status: http.StatusInternalServerError,
+		Title:  "Response Validation Error",
+		Detail: message,
+	})
+}
+
+// documentsMediaType reports whether the response of the operation for the status is documented in the media type.
+func documentsMediaType(operation *openapi3.Operation, status int, mediaType string) bool {
+	response := operation.Responses.Status(status)
+	if response == nil {
+		response = operation.Responses.Default()
+	}
+	return response != nil && response.Value != nil && response.Value.Content.Get(mediaType) != nil
+}
+
+// responseValidationMessage returns the reason of the response validation error, with the invalid fields of the body.
+func responseValidationMessage(err error) string {
+	var responseError *openapi3filter.ResponseError
+	if !errors.As(err, &responseError) {
+		return err.Error()
+	}
+
+	var reasons []string
+	for _, err := range unwrapMultiError(responseError.Err) {
+		var schemaError *openapi3.SchemaError
+		if !errors.As(err, &schemaError) {
+			reasons = append(reasons, err.Error())
+			continue
+		}
+		reason := schemaError.Reason
+		if name, _ := schemaErrorPath(schemaError); name != "" {
+			reason = name + ": " + reason
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		return responseError.Reason
+	}
+	return responseError.Reason + ": " + strings.Join(reasons, ", ")
+}
+
+// responseRecorder is a [gin.ResponseWriter] buffering the response of the controller, to validate it before sending it.
+// Once flushed or hijacked, the response is streamed to the underlying response writer.
+type responseRecorder struct {
+	gin.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	written  bool
+	streamed bool
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	if w.streamed {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *responseRecorder) WriteHeaderNow() {
+	if w.streamed {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	if w.streamed {
+		return w.ResponseWriter.Write(data)
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) { return w.Write([]byte(s)) }
+
+func (w *responseRecorder) Status() int {
+	if w.streamed {
+		return w.ResponseWriter.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *responseRecorder) Size() int {
+	if w.streamed {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *responseRecorder) Written() bool {
+	if w.streamed {
+		return w.ResponseWriter.Written()
+	}
+	return w.written
+}
+
+func (w *responseRecorder) Flush() {
+	if !w.streamed {
+		w.send()
+		w.streamed = true
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.streamed = true
+	return w.ResponseWriter.Hijack()
+}
+
+// send writes the buffered response to the underlying response writer.
+func (w *responseRecorder) send() {
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+	}
+}
@@ -0,0 +1,242 @@
+package fuego
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type filteredItem struct {
+	Name  string   `json:"name" validate:"required,min=3"`
+	Price float64  `json:"price" validate:"gt=0"`
+	Tags  []string `json:"tags" validate:"dive,min=2"`
+}
+
+type filteredParams struct {
+	ID    int `path:"id"`
+	Limit int `query:"limit"`
+}
+
+func TestRequestValidation(t *testing.T) {
+	newServer := func(options ...func(*Server)) *Server {
+		s := NewServer(append([]func(*Server){WithoutLogger()}, options...)...)
+
+		Post(s.RouterGroup(), "/items", func(c *ContextWithBody[filteredItem]) (filteredItem, error) {
+			return c.Body()
+		})
+
+		Get(s.RouterGroup(), "/items/:id", func(c *ContextWithParams[filteredParams]) (filteredParams, error) {
+			return c.Params()
+		})
+
+		PostGin(s.RouterGroup(), "/gin/items", func(c *gin.Context) {
+			c.Status(http.StatusCreated)
+		}).WithRequest(filteredItem{})
+
+		return s
+	}
+
+	send := func(s *Server, method, path, contentType, body string) (*httptest.ResponseRecorder, HTTPError) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		if contentType != "" {
+			r.Header.Set("Content-Type", contentType)
+		}
+		s.ServeHTTP(w, r)
+
+		var problem HTTPError
+		if w.Code >= 400 {
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem), w.Body.String())
+		}
+		return w, problem
+	}
+
+	t.Run("accepts valid requests", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		w, _ := send(s, http.MethodPost, "/items", "application/json", `{"name":"book","price":12.5,"tags":["ab"]}`)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"name":"book","price":12.5,"tags":["ab"]}`, w.Body.String(), "the body is read again by the controller")
+
+		w, _ = send(s, http.MethodGet, "/items/3?limit=10", "", "")
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("lists the invalid body fields", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		w, problem := send(s, http.MethodPost, "/items", "application/json", `{"name":"ab","price":0,"tags":["ok","x"]}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Equal(t, "Request Validation Error", problem.Title)
+
+		pointers := map[string]string{}
+		for _, item := range problem.Errors {
+			require.Equal(t, "body", item.In)
+			pointers[item.Pointer] = item.Name
+		}
+		require.Equal(t, map[string]string{
+			"/name":   "name",
+			"/price":  "price",
+			"/tags/1": "tags[1]",
+		}, pointers)
+	})
+
+	t.Run("lists the invalid parameters", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		w, problem := send(s, http.MethodGet, "/items/abc?limit=many", "", "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Len(t, problem.Errors, 2)
+
+		locations := map[string]string{}
+		for _, item := range problem.Errors {
+			locations[item.Name] = item.In
+			require.NotEmpty(t, item.Reason)
+		}
+		require.Equal(t, map[string]string{"id": "path", "limit": "query"}, locations)
+	})
+
+	t.Run("validates the gin routes documented with WithRequest", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		w, problem := send(s, http.MethodPost, "/gin/items", "application/json", `{"name":"ab","price":1}`)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Len(t, problem.Errors, 1)
+		require.Equal(t, "/name", problem.Errors[0].Pointer)
+
+		w, _ = send(s, http.MethodPost, "/gin/items", "application/json", `{"name":"abc","price":1}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+
+	t.Run("rejects undocumented content types", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		w, _ := send(s, http.MethodPost, "/gin/items", "text/plain", `name=abc`)
+		require.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("leaves the file uploads to the controllers", func(t *testing.T) {
+		s := newServer(WithRequestValidation())
+
+		Post(s.RouterGroup(), "/uploads", func(c *ContextWithBody[uploadBody]) (string, error) {
+			body, err := c.Body()
+			if err != nil {
+				return "", err
+			}
+			return body.Avatar.Filename, nil
+		})
+
+		PostGin(s.RouterGroup(), "/gin/uploads", func(c *gin.Context) {
+			c.Status(http.StatusAccepted)
+		}).WithRequest(uploadBody{})
+
+		upload := func(path string, fields map[string]string) (*httptest.ResponseRecorder, *countingReader) {
+			r := newMultipartRequest(t, fields, map[string][]string{"avatar": {strings.Repeat("a", 1<<16)}})
+			r.URL.Path = path
+			body := &countingReader{r: r.Body}
+			r.Body = io.NopCloser(body)
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, r)
+			return w, body
+		}
+
+		w, _ := upload("/uploads", map[string]string{"title": "holidays"})
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.JSONEq(t, `"avatar-0.txt"`, w.Body.String())
+
+		w, _ = upload("/uploads", nil)
+		require.Equal(t, http.StatusBadRequest, w.Code, "the controller validates the form")
+
+		w, body := upload("/gin/uploads", map[string]string{"title": "holidays"})
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Zero(t, body.n, "the body is not read in memory by the validation")
+	})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		s := newServer()
+
+		w, _ := send(s, http.MethodPost, "/gin/items", "application/json", `{"name":"ab","price":1}`)
+		require.Equal(t, http.StatusCreated, w.Code)
+	})
+}
+
+func TestResponseValidation(t *testing.T) {
+	newServer := func(strict bool) *Server {
+		s := NewServer(WithoutLogger(), WithResponseValidation(strict))
+
+		Get(s.RouterGroup(), "/items", func(c ContextNoBody) (filteredItem, error) {
+			return filteredItem{Name: "book", Price: 12.5, Tags: []string{"paper"}}, nil
+		})
+
+		Get(s.RouterGroup(), "/missing", func(c ContextNoBody) (filteredItem, error) {
+			return filteredItem{}, BadRequestError{Detail: "invalid item"}
+		})
+
+		GetGin(s.RouterGroup(), "/drifted", func(c *gin.Context) {
+			c.Header("X-Drift", "yes")
+			c.Data(http.StatusOK, "application/json", []byte(`{"name":12,"price":"free"}`))
+		}).WithResponse(filteredItem{})
+
+		GetGin(s.RouterGroup(), "/streamed", func(c *gin.Context) {
+			c.Data(http.StatusOK, "application/json", []byte(`{"name":12}`))
+			c.Writer.Flush()
+		}).WithResponse(filteredItem{})
+
+		return s
+	}
+
+	get := func(s *Server, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("sends the valid responses", func(t *testing.T) {
+		s := newServer(true)
+
+		w := get(s, "/items")
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		require.JSONEq(t, `{"name":"book","price":12.5,"tags":["paper"]}`, w.Body.String())
+
+		w = get(s, "/missing")
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		require.Contains(t, w.Body.String(), "invalid item")
+	})
+
+	t.Run("replaces the invalid responses in strict mode", func(t *testing.T) {
+		s := newServer(true)
+
+		w := get(s, "/drifted")
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+
+		var problem HTTPError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		require.Equal(t, "Response Validation Error", problem.Title)
+		require.Contains(t, problem.Detail, "name: value must be a string")
+		require.Contains(t, problem.Detail, "price: value must be a number")
+	})
+
+	t.Run("only logs the invalid responses", func(t *testing.T) {
+		s := newServer(false)
+
+		w := get(s, "/drifted")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "yes", w.Header().Get("X-Drift"))
+		require.JSONEq(t, `{"name":12,"price":"free"}`, w.Body.String())
+	})
+
+	t.Run("does not validate the streamed responses", func(t *testing.T) {
+		s := newServer(true)
+
+		w := get(s, "/streamed")
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"name":12}`, w.Body.String())
+	})
+}
@@ -2,6 +2,8 @@ package fuego
 
 import (
 	"log/slog"
+	"net/http"
+	"reflect"
 	"slices"
 
 	"github.com/getkin/kin-openapi/openapi3"
@@ -77,20 +79,17 @@ func WithAllowReserved() func(opt *openapi3.Parameter) {
 
 // Overrides the description for the route.
 func (r Route) Description(description string) Route {
-	r.Operation.Description = description
-	return r
+	return r.update(func(r *Route) { r.Operation.Description = description })
 }
 
 // Overrides the summary for the route.
 func (r Route) Summary(summary string) Route {
-	r.Operation.Summary = summary
-	return r
+	return r.update(func(r *Route) { r.Operation.Summary = summary })
 }
 
 // Overrides the operationID for the route.
 func (r Route) OperationID(operationID string) Route {
-	r.Operation.OperationID = operationID
-	return r
+	return r.update(func(r *Route) { r.Operation.OperationID = operationID })
 }
 
 // Param registers a parameter for the route.
@@ -106,40 +105,33 @@ func (r Route) Param(paramType ParamType, name, description string, opts ...func
 		opt(openapiParam)
 	}
 
-	r.Operation.AddParameter(openapiParam)
-
-	return r
+	return r.update(func(r *Route) { r.Operation.AddParameter(openapiParam) })
 }
 
 // Header registers a header parameter for the route.
 func (r Route) Header(name, description string, opts ...func(*openapi3.Parameter)) Route {
-	r.Param(HeaderParamType, name, description, opts...)
-	return r
+	return r.Param(HeaderParamType, name, description, opts...)
 }
 
 // Cookie registers a cookie parameter for the route.
 func (r Route) Cookie(name, description string, opts ...func(*openapi3.Parameter)) Route {
-	r.Param(CookieParamType, name, description, opts...)
-	return r
+	return r.Param(CookieParamType, name, description, opts...)
 }
 
 // QueryParam registers a query parameter for the route.
 func (r Route) Query(name, description string, opts ...func(*openapi3.Parameter)) Route {
-	r.Param(QueryParamType, name, description, opts...)
-	return r
+	return r.Param(QueryParamType, name, description, opts...)
 }
 
 // Replace the tags for the route.
 // By default, the tag is the type of the response body.
 func (r Route) Tags(tags ...string) Route {
-	r.Operation.Tags = tags
-	return r
+	return r.update(func(r *Route) { r.Operation.Tags = tags })
 }
 
 // AddTags adds tags to the route.
 func (r Route) AddTags(tags ...string) Route {
-	r.Operation.Tags = append(r.Operation.Tags, tags...)
-	return r
+	return r.update(func(r *Route) { r.Operation.Tags = append(r.Operation.Tags, tags...) })
 }
 
 // AddError adds an error to the route.
@@ -154,93 +146,197 @@ func (r Route) AddError(code int, errType any, description string, contentType .
 		ContentType: contentType,
 	}
 
-	r.Errors = append(r.Errors, openAPIError{
-		Code:   code,
-		Schema: schema,
+	return r.update(func(r *Route) {
+		r.Errors = append(r.Errors, openAPIError{
+			Code:   code,
+			Schema: schema,
+		})
 	})
-
-	return r
 }
 
 // RemoveTags removes tags from the route.
 func (r Route) RemoveTags(tags ...string) Route {
-	for _, tag := range tags {
-		for i, t := range r.Operation.Tags {
-			if t == tag {
-				r.Operation.Tags = slices.Delete(r.Operation.Tags, i, i+1)
-				break
+	return r.update(func(r *Route) {
+		for _, tag := range tags {
+			for i, t := range r.Operation.Tags {
+				if t == tag {
+					r.Operation.Tags = slices.Delete(r.Operation.Tags, i, i+1)
+					break
+				}
 			}
 		}
-	}
-	return r
+	})
 }
 
 func (r Route) Deprecated() Route {
-	r.Operation.Deprecated = true
-	return r
+	return r.update(func(r *Route) { r.Operation.Deprecated = true })
 }
 
 func (r Route) WithRequest(reqType any, contentType ...string) Route {
-	if len(contentType) == 0 {
-		contentType = append(contentType, "application/json")
+	if len(contentType) == 0 && len(formFileFields(reflect.TypeOf(reqType))) > 0 {
+		contentType = append(contentType, "multipart/form-data")
 	}
 
-	r.Request = Schema{
-		Type:        reqType,
-		ContentType: contentType,
-	}
+	return r.update(func(r *Route) {
+		r.Request = Schema{
+			Type:        reqType,
+			ContentType: contentType,
+		}
+	})
+}
 
-	return r
+// WithParams documents the parameters from the tagged fields of the given struct.
+// It is set automatically for controllers using [ContextWithParams] or [ContextFull].
+func (r Route) WithParams(params any) Route {
+	return r.update(func(r *Route) { r.Params = params })
 }
 
 func (r Route) RequestDescription(desc string) Route {
-	r.Request.Description = desc
-	return r
+	return r.update(func(r *Route) { r.Request.Description = desc })
 }
 
 func (r Route) ResponseDescription(desc string) Route {
-	r.Response.Description = desc
-	return r
+	return r.update(func(r *Route) { r.Response.Description = desc })
+}
+
+// Status sets the status code of successful responses, both in the OpenAPI spec and at runtime.
+// The controller can still override it with [ContextNoBody.SetStatus] or by returning a [Response].
+// For example:
+//
+//	fuego.Post(s.RouterGroup(), "/recipes", createRecipe).Status(http.StatusCreated)
+func (r Route) Status(code int) Route {
+	return r.update(func(r *Route) { r.DefaultStatusCode = code })
+}
+
+// ResponseHeader documents a header sent with successful responses.
+// Set its value at runtime with [ContextNoBody.SetHeader] or by returning a [Response].
+// The header schema defaults to a string.
+func (r Route) ResponseHeader(name, description string, opts ...func(*openapi3.Header)) Route {
+	header := &openapi3.Header{
+		Parameter: openapi3.Parameter{
+			Description: description,
+			Schema:      openapi3.NewStringSchema().NewRef(),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(header)
+	}
+
+	return r.update(func(r *Route) {
+		headers := make(openapi3.Headers, len(r.ResponseHeaders)+1)
+		for key, value := range r.ResponseHeaders {
+			headers[key] = value
+		}
+		headers[name] = &openapi3.HeaderRef{Value: header}
+		r.ResponseHeaders = headers
+	})
+}
+
+// successStatus returns the status code of successful responses.
+func (r Route) successStatus() int {
+	if r.DefaultStatusCode != 0 {
+		return r.DefaultStatusCode
+	}
+	return http.StatusOK
 }
 
+// WithResponse sets the response type of the route, and the content types it can be serialized to.
+// The declared content types restrict the content negotiation of [Send].
+// If none is declared, any supported content type can be negotiated, and the spec documents application/json.
+// The iter.Seq[T] and channels of T are documented as arrays of T, streamed as JSON or NDJSON,
+// and with [WithContentNegotiation], in the formats of the codecs streaming them, like [CSVCodec].
 func (r Route) WithResponse(resType any, contentType ...string) Route {
-	if len(contentType) == 0 {
-		contentType = append(contentType, "application/json")
+	if describer, ok := resType.(responseDescriber); ok {
+		var describedContentType string
+		resType, describedContentType = describer.describeResponse()
+		if len(contentType) == 0 && describedContentType != "" {
+			contentType = append(contentType, describedContentType)
+		}
 	}
 
-	r.Response = Schema{
-		Type:        resType,
-		ContentType: contentType,
+	elem, isSequence := sequenceElem(reflect.TypeOf(resType))
+	if isSequence {
+		resType = reflect.Zero(reflect.SliceOf(elem)).Interface()
 	}
 
-	return r
+	return r.update(func(r *Route) {
+		r.Response = Schema{
+			Type:        resType,
+			ContentType: contentType,
+			sequence:    elem,
+		}
+	})
 }
 
 func (r Route) RequestContentType(contentType string) Route {
-	r.Request.ContentType = []string{contentType}
-	return r
+	return r.update(func(r *Route) { r.Request.ContentType = []string{contentType} })
 }
 
 func (r Route) ResponseContentType(contentType string) Route {
-	r.Response.ContentType = []string{contentType}
-	return r
+	return r.update(func(r *Route) { r.Response.ContentType = []string{contentType} })
 }
 
 func (r Route) With(opts func(Route) Route) Route {
-	return opts(r)
+	return r.update(func(r *Route) { *r = opts(*r) })
 }
 
+// Build finalizes the route: the group route configurations are applied
+// and the route is documented in the OpenAPI spec.
+// Calling it is optional: every registered route is finalized
+// when the server starts or when the OpenAPI spec is output.
 func (r Route) Build() {
-	for _, opt := range r.Group.routeCfg {
-		r = r.With(opt)
+	if r.entry == nil {
+		r.finalize()
+		return
+	}
+
+	r.entry.finalize()
+}
+
+// update applies the modification to the route stored in the server registry, so that it is taken
+// into account when the route is finalized, and returns the up to date route.
+// The modification is applied to the registry entry rather than to r, so modifying a stale copy
+// of the route keeps the modifications made with the other copies.
+func (r Route) update(modify func(r *Route)) Route {
+	if r.entry == nil {
+		modify(&r)
+		return r
+	}
+
+	if r.entry.finalized {
+		if r.mainRouter != nil && r.mainRouter.strictRoutes {
+			panic("route " + r.Method + " " + r.Path + " modified after being finalized")
+		}
+		slog.Warn("Route modified after being finalized, changes might not be reflected in the OpenAPI spec", "method", r.Method, "path", r.Path)
+		modify(&r)
+		return r
+	}
+
+	modify(r.entry)
+	return *r.entry
+}
+
+// finalize applies the group route configurations and documents the route in the OpenAPI spec.
+// It does nothing if the route has already been finalized.
+func (r *Route) finalize() {
+	if r.finalized {
+		return
+	}
+
+	route := *r
+	for _, opt := range route.Group.routeCfg {
+		route = route.With(opt)
 	}
+	route.finalized = true
+	*r = route
 
 	if r.Group.DisableOpenapi || r.Method == "" || r.All {
 		return
 	}
 
 	var err error
-	r.Operation, err = RegisterOpenAPIOperation(r.Group, r)
+	r.Operation, err = RegisterOpenAPIOperation(r.Group, *r)
 	if err != nil {
 		slog.Warn("error documenting openapi operation", "error", err)
 	}
@@ -249,3 +345,10 @@ func (r Route) Build() {
 		r.Operation.OperationID = r.Method + "_" + r.Path
 	}
 }
+
+// finalizeRoutes finalizes all the registered routes that have not been finalized yet.
+func (s *Server) finalizeRoutes() {
+	for _, route := range s.routes {
+		route.finalize()
+	}
+}
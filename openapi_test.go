@@ -1,9 +1,15 @@
 package fuego
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/stretchr/testify/require"
@@ -341,3 +347,309 @@ func TestValidationTags(t *testing.T) {
 	require.Equal(t, float64(18.0), *myTypeValue.Properties["age"].Value.Min)
 	require.Equal(t, float64(100), *myTypeValue.Properties["age"].Value.Max)
 }
+
+type validatedAddress struct {
+	Zip     string `json:"zip" validate:"required,len=5,numeric"`
+	Country string `json:"country" validate:"oneof=FR 'United States'"`
+}
+
+type validatedAudit struct {
+	Author string `json:"author" validate:"required,email"`
+}
+
+type validatedOrder struct {
+	validatedAudit
+	ID       string             `json:"id" validate:"uuid"`
+	Code     string             `json:"code" validate:"startswith=ORD,endswith=Z,alphanum"`
+	Price    float64            `json:"price" validate:"gt=0,lte=1000.5"`
+	Quantity int                `json:"quantity" validate:"gte=1,lt=100"`
+	Priority int                `json:"priority" validate:"oneof=1 2 3"`
+	Tags     []string           `json:"tags" validate:"min=1,max=5,dive,min=2,alpha"`
+	Website  string             `json:"website" validate:"omitempty,url"`
+	IP       string             `json:"ip" validate:"ipv4"`
+	Day      string             `json:"day" validate:"datetime=2006-01-02"`
+	Timeout  time.Duration      `json:"timeout" validate:"max=1m"`
+	Address  validatedAddress   `json:"address"`
+	Previous []validatedAddress `json:"previous" validate:"dive"`
+	Notes    map[string]string  `json:"notes" validate:"max=3,dive,keys,min=1,endkeys,max=20"`
+	Broken   int                `json:"broken" validate:"min=abc"`
+}
+
+func TestValidationTagsMapping(t *testing.T) {
+	s := NewServer(WithoutLogger())
+	Post(s.RouterGroup(), "/orders", func(*ContextWithBody[validatedOrder]) (validatedOrder, error) {
+		return validatedOrder{}, nil
+	})
+
+	s.OutputOpenAPISpec()
+	order := s.OpenApiSpec.Components.Schemas["ValidatedOrder"].Value
+	property := func(name string) *openapi3.Schema { return order.Properties[name].Value }
+
+	t.Run("numbers", func(t *testing.T) {
+		require.Equal(t, 0.0, *property("price").Min)
+		require.True(t, property("price").ExclusiveMin)
+		require.Equal(t, 1000.5, *property("price").Max)
+		require.False(t, property("price").ExclusiveMax)
+
+		require.Equal(t, 1.0, *property("quantity").Min)
+		require.Equal(t, 100.0, *property("quantity").Max)
+		require.True(t, property("quantity").ExclusiveMax)
+
+		require.Equal(t, []any{1.0, 2.0, 3.0}, property("priority").Enum)
+		require.Equal(t, float64(time.Minute), *property("timeout").Max)
+	})
+
+	t.Run("formats and patterns", func(t *testing.T) {
+		require.Equal(t, "uuid", property("id").Format)
+		require.Equal(t, "uri", property("website").Format)
+		require.Equal(t, "ipv4", property("ip").Format)
+		require.Equal(t, "date", property("day").Format)
+		require.Equal(t, "email", property("author").Format)
+
+		require.Equal(t, "^ORD", property("code").Pattern)
+		require.Len(t, property("code").AllOf, 2)
+		require.Equal(t, "Z$", property("code").AllOf[0].Value.Pattern)
+		require.Equal(t, `^[a-zA-Z0-9]+$`, property("code").AllOf[1].Value.Pattern)
+		require.Contains(t, order.Required, "author")
+	})
+
+	t.Run("slices and maps", func(t *testing.T) {
+		require.Equal(t, uint64(1), property("tags").MinItems)
+		require.Equal(t, uint64(5), *property("tags").MaxItems)
+		require.Equal(t, uint64(2), property("tags").Items.Value.MinLength)
+		require.Equal(t, `^[a-zA-Z]+$`, property("tags").Items.Value.Pattern)
+
+		require.Equal(t, uint64(3), *property("notes").MaxProps)
+		require.Equal(t, uint64(20), *property("notes").AdditionalProperties.Schema.Value.MaxLength)
+	})
+
+	t.Run("nested structs", func(t *testing.T) {
+		address := property("address")
+		require.Equal(t, []string{"zip"}, address.Required)
+		require.Equal(t, uint64(5), address.Properties["zip"].Value.MinLength)
+		require.Equal(t, uint64(5), *address.Properties["zip"].Value.MaxLength)
+		require.Equal(t, []any{"FR", "United States"}, address.Properties["country"].Value.Enum)
+
+		previous := property("previous").Items.Value
+		require.Equal(t, []string{"zip"}, previous.Required)
+	})
+
+	t.Run("ignores unparseable tags", func(t *testing.T) {
+		require.Nil(t, property("broken").Min)
+	})
+}
+
+func TestWithOpenAPIConfig(t *testing.T) {
+	t.Run("serves the spec and the UI on custom URLs", func(t *testing.T) {
+		jsonFilePath := filepath.Join(t.TempDir(), "docs", "openapi.json")
+		s := NewServer(
+			WithoutLogger(),
+			WithOpenAPIConfig(OpenAPIConfig{
+				JsonUrl:      "/api/openapi.json",
+				SwaggerUrl:   "/api/docs",
+				JsonFilePath: jsonFilePath,
+			}),
+		)
+		Get(s.RouterGroup(), "/", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), `"openapi":"3.1.0"`)
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/api/docs/index.html", nil)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `apiDescriptionUrl="/api/openapi.json"`)
+
+		_, err := os.Stat(jsonFilePath)
+		require.ErrorIs(t, err, os.ErrNotExist, "the spec is only saved when the server runs")
+
+		require.Nil(t, s.OpenApiSpec.Paths.Find("/api/openapi.json"), "spec routes are hidden")
+	})
+
+	t.Run("saves the spec when the server runs", func(t *testing.T) {
+		jsonFilePath := filepath.Join(t.TempDir(), "docs", "openapi.json")
+		s := NewServer(
+			WithoutLogger(),
+			WithOpenAPIConfig(OpenAPIConfig{JsonFilePath: jsonFilePath}),
+		)
+		Get(s.RouterGroup(), "/", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.NoError(t, s.serve(ctx, listener))
+
+		saved, err := os.ReadFile(jsonFilePath)
+		require.NoError(t, err)
+		require.Equal(t, s.openAPISpecJSON, saved)
+	})
+
+	t.Run("disable swagger and local save", func(t *testing.T) {
+		s := NewServer(
+			WithoutLogger(),
+			WithOpenAPIConfig(OpenAPIConfig{
+				DisableSwagger:   true,
+				DisableLocalSave: true,
+			}),
+		)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/swagger/openapi.json", nil)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("disable swagger UI only", func(t *testing.T) {
+		s := NewServer(
+			WithoutLogger(),
+			WithOpenAPIConfig(OpenAPIConfig{
+				DisableSwaggerUI: true,
+				DisableLocalSave: true,
+			}),
+		)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/swagger/index.html", nil)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequest(http.MethodGet, "/swagger/openapi.json", nil)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("invalid URLs", func(t *testing.T) {
+		require.Panics(t, func() {
+			NewServer(WithOpenAPIConfig(OpenAPIConfig{JsonUrl: "openapi.json"}))
+		})
+		require.Panics(t, func() {
+			NewServer(WithOpenAPIConfig(OpenAPIConfig{JsonUrl: "/openapi.yaml"}))
+		})
+		require.Panics(t, func() {
+			NewServer(WithOpenAPIConfig(OpenAPIConfig{SwaggerUrl: "/docs/"}))
+		})
+	})
+}
+
+func TestRouteFinalization(t *testing.T) {
+	t.Run("routes are documented without calling Build", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		group := Group(s.RouterGroup(), "/group").Header("X-Group", "group header")
+		group.RouteConfig(func(r Route) Route {
+			return r.AddTags("configured")
+		})
+
+		Get(group, "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		}).
+			Summary("summary").
+			AddError(http.StatusConflict, HTTPError{}, "Conflict")
+
+		document := s.OutputOpenAPISpec()
+		operation := document.Paths.Find("/group/a").Get
+		require.NotNil(t, operation)
+		require.Equal(t, "summary", operation.Summary)
+		require.Contains(t, operation.Tags, "configured")
+		require.NotNil(t, operation.Parameters.GetByInAndName("header", "X-Group"))
+		require.NotNil(t, operation.Responses.Value("409"))
+		require.NotNil(t, operation.Responses.Value("400"), "global responses are applied")
+	})
+
+	t.Run("modifying a stale copy keeps the other modifications", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		route := Get(s.RouterGroup(), "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+
+		route.Status(http.StatusAccepted)
+		route.Summary("summary")
+		route.AddTags("first")
+		route.AddTags("second")
+
+		document := s.OutputOpenAPISpec()
+		operation := document.Paths.Find("/a").Get
+		require.Equal(t, "summary", operation.Summary)
+		require.Equal(t, []string{"first", "second"}, operation.Tags)
+		require.NotNil(t, operation.Responses.Value("202"))
+	})
+
+	t.Run("Build is idempotent", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		group := Group(s.RouterGroup(), "/group").Header("X-Group", "group header")
+
+		Get(group, "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		}).Build()
+
+		document := s.OutputOpenAPISpec()
+		require.Len(t, document.Paths.Find("/group/a").Get.Parameters, 1)
+	})
+
+	t.Run("routes in hidden groups are not documented", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		hidden := Group(s.RouterGroup(), "/hidden").Hide()
+		subGroup := Group(hidden, "/sub")
+
+		Get(subGroup, "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+
+		document := s.OutputOpenAPISpec()
+		require.Nil(t, document.Paths.Find("/hidden/sub/a"))
+	})
+
+	t.Run("modifying a finalized route", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		route := Get(s.RouterGroup(), "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+		s.OutputOpenAPISpec()
+
+		require.NotPanics(t, func() {
+			route.AddError(http.StatusConflict, HTTPError{}, "Conflict")
+		})
+	})
+
+	t.Run("modifying a finalized route in strict mode", func(t *testing.T) {
+		s := NewServer(WithoutLogger(), WithStrictRoutes())
+		route := Get(s.RouterGroup(), "/a", func(ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, nil
+		})
+		s.OutputOpenAPISpec()
+
+		require.Panics(t, func() {
+			route.AddError(http.StatusConflict, HTTPError{}, "Conflict")
+		})
+	})
+}
+
+func TestMultipartRequestBody(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Post(s.RouterGroup(), "/upload", func(c *ContextWithBody[uploadBody]) (string, error) {
+		return "ok", nil
+	})
+	s.OutputOpenAPISpec()
+
+	requestBody := s.OpenApiSpec.Paths.Find("/upload").Post.RequestBody
+	require.NotNil(t, requestBody)
+	mediaType := requestBody.Value.Content.Get("multipart/form-data")
+	require.NotNil(t, mediaType)
+	require.Nil(t, requestBody.Value.Content.Get("application/json"))
+
+	schema := s.OpenApiSpec.Components.Schemas["UploadBody"].Value
+	require.Equal(t, "binary", schema.Properties["Avatar"].Value.Format)
+	require.True(t, schema.Properties["Attachments"].Value.Type.Is(openapi3.TypeArray))
+	require.Equal(t, "binary", schema.Properties["Attachments"].Value.Items.Value.Format)
+}
@@ -0,0 +1,311 @@
+package fuego
+
+import (
+	"errors"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// validateFormats are the OpenAPI formats of the string validate tags.
+var validateFormats = map[string]string{
+	"email":            "email",
+	"uuid":             "uuid",
+	"uuid3":            "uuid",
+	"uuid4":            "uuid",
+	"uuid5":            "uuid",
+	"uuid_rfc4122":     "uuid",
+	"url":              "uri",
+	"http_url":         "uri",
+	"uri":              "uri",
+	"ip":               "ip",
+	"ip_addr":          "ip",
+	"ipv4":             "ipv4",
+	"ip4_addr":         "ipv4",
+	"ipv6":             "ipv6",
+	"ip6_addr":         "ipv6",
+	"hostname":         "hostname",
+	"hostname_rfc1123": "hostname",
+	"fqdn":             "hostname",
+	"base64":           "byte",
+}
+
+// validatePatterns are the regular expressions of the string validate tags.
+var validatePatterns = map[string]string{
+	"alpha":       `^[a-zA-Z]+$`,
+	"alphanum":    `^[a-zA-Z0-9]+$`,
+	"numeric":     `^[-+]?[0-9]+(?:\.[0-9]+)?$`,
+	"number":      `^[0-9]+$`,
+	"hexadecimal": `^(0[xX])?[0-9a-fA-F]+$`,
+	"e164":        `^\+[1-9]?[0-9]{7,14}$`,
+}
+
+// datetimeFormats are the OpenAPI formats of the layouts of the datetime validate tag.
+var datetimeFormats = map[string]string{
+	time.RFC3339:     "date-time",
+	time.RFC3339Nano: "date-time",
+	time.DateOnly:    "date",
+}
+
+// oneofValues splits the values of the oneof validate tag, like the validator does.
+var oneofValues = regexp.MustCompile(`'[^']*'|\S+`)
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// documentValidateTags documents the constraints of the validate tags (ex: "min=1,dive,email")
+// of the values of type t in their schema. The tags after dive apply to the items of the slices
+// and to the values of the maps. The tags with unparseable parameters are ignored with a warning.
+func (s *Server) documentValidateTags(t reflect.Type, schema *openapi3.Schema, tags []string) {
+	t = indirectType(t)
+
+	for i, tag := range tags {
+		name, param, _ := strings.Cut(tag, "=")
+
+		if name == "dive" {
+			rest := tags[i+1:]
+			if t.Kind() == reflect.Map && len(rest) > 0 && rest[0] == "keys" {
+				// The constraints of the keys cannot be documented.
+				for len(rest) > 0 && rest[0] != "endkeys" {
+					rest = rest[1:]
+				}
+				if len(rest) > 0 {
+					rest = rest[1:]
+				}
+			}
+			if elem := elementSchema(t, schema); elem != nil {
+				s.documentValidateTags(t.Elem(), elem, rest)
+			}
+			return
+		}
+
+		if rule, ok := s.validator.rules[name]; ok {
+			if rule.Schema != nil {
+				rule.Schema(schema, param)
+			}
+			continue
+		}
+
+		err := documentValidateTag(t, schema, name, param)
+		if err != nil {
+			slog.Warn("Cannot document the validate tag in the OpenAPI schema", "tag", tag, "type", t.String(), "error", err)
+		}
+	}
+}
+
+// elementSchema returns the schema of the items of the slices, or of the values of the maps.
+func elementSchema(t reflect.Type, schema *openapi3.Schema) *openapi3.Schema {
+	var elem *openapi3.SchemaRef
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elem = schema.Items
+	case reflect.Map:
+		elem = schema.AdditionalProperties.Schema
+	}
+	if elem == nil || elem.Ref != "" {
+		return nil
+	}
+	return elem.Value
+}
+
+// documentValidateTag documents the constraint of a validate tag of the values of type t in their schema.
+// The tags without OpenAPI equivalent, like the cross-field ones, are ignored.
+func documentValidateTag(t reflect.Type, schema *openapi3.Schema, name, param string) error {
+	if strings.Contains(name, "|") {
+		// Alternatives (ex: "email|url") cannot be documented with the keywords of a single schema.
+		return nil
+	}
+
+	switch name {
+	case "min", "gte":
+		return setMinimum(t, schema, param, false)
+	case "gt":
+		return setMinimum(t, schema, param, true)
+	case "max", "lte":
+		return setMaximum(t, schema, param, false)
+	case "lt":
+		return setMaximum(t, schema, param, true)
+	case "len", "eq":
+		if name == "eq" && t.Kind() == reflect.String {
+			schema.Enum = []any{param}
+			return nil
+		}
+		err := setMinimum(t, schema, param, false)
+		if err != nil {
+			return err
+		}
+		return setMaximum(t, schema, param, false)
+	case "oneof":
+		return setEnum(t, schema, param)
+	case "datetime":
+		if format, ok := datetimeFormats[param]; ok {
+			schema.Format = format
+		}
+		return nil
+	case "startswith":
+		addPattern(schema, "^"+regexp.QuoteMeta(param))
+		return nil
+	case "endswith":
+		addPattern(schema, regexp.QuoteMeta(param)+"$")
+		return nil
+	case "contains":
+		addPattern(schema, regexp.QuoteMeta(param))
+		return nil
+	}
+
+	if format, ok := validateFormats[name]; ok {
+		schema.Format = format
+	} else if pattern, ok := validatePatterns[name]; ok {
+		addPattern(schema, pattern)
+	}
+	return nil
+}
+
+// addPattern documents a pattern the strings must match. A schema has a single pattern,
+// so the patterns of the following tags are added as allOf subschemas instead of replacing it.
+func addPattern(schema *openapi3.Schema, pattern string) {
+	if schema.Pattern == "" || schema.Pattern == pattern {
+		schema.Pattern = pattern
+		return
+	}
+	for _, sub := range schema.AllOf {
+		if sub.Value != nil && sub.Value.Pattern == pattern {
+			return
+		}
+	}
+	schema.AllOf = append(schema.AllOf, openapi3.NewSchemaRef("", &openapi3.Schema{Pattern: pattern}))
+}
+
+// setMinimum documents the minimum of the numbers, or the minimum length of the strings, slices and maps.
+func setMinimum(t reflect.Type, schema *openapi3.Schema, param string, exclusive bool) error {
+	if param == "" {
+		// Comparison with the current time, for time.Time values.
+		return nil
+	}
+
+	switch {
+	case isNumberKind(t.Kind()):
+		min, err := parseNumber(t, param)
+		if err != nil {
+			return err
+		}
+		schema.Min = &min
+		schema.ExclusiveMin = exclusive
+	case isLengthKind(t):
+		min, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			min++
+		}
+		switch t.Kind() {
+		case reflect.String:
+			schema.MinLength = min
+		case reflect.Map:
+			schema.MinProps = min
+		default:
+			schema.MinItems = min
+		}
+	}
+
+	return nil
+}
+
+// setMaximum documents the maximum of the numbers, or the maximum length of the strings, slices and maps.
+func setMaximum(t reflect.Type, schema *openapi3.Schema, param string, exclusive bool) error {
+	if param == "" {
+		// Comparison with the current time, for time.Time values.
+		return nil
+	}
+
+	switch {
+	case isNumberKind(t.Kind()):
+		max, err := parseNumber(t, param)
+		if err != nil {
+			return err
+		}
+		schema.Max = &max
+		schema.ExclusiveMax = exclusive
+	case isLengthKind(t):
+		max, err := strconv.ParseUint(param, 10, 64)
+		if err != nil {
+			return err
+		}
+		if exclusive {
+			if max == 0 {
+				return errors.New("no length is lower than 0")
+			}
+			max--
+		}
+		switch t.Kind() {
+		case reflect.String:
+			schema.MaxLength = &max
+		case reflect.Map:
+			schema.MaxProps = &max
+		default:
+			schema.MaxItems = &max
+		}
+	}
+
+	return nil
+}
+
+// setEnum documents the values of the oneof validate tag.
+func setEnum(t reflect.Type, schema *openapi3.Schema, param string) error {
+	values := oneofValues.FindAllString(param, -1)
+	enum := make([]any, 0, len(values))
+	for _, value := range values {
+		value = strings.Trim(value, "'")
+		if !isNumberKind(t.Kind()) {
+			enum = append(enum, value)
+			continue
+		}
+
+		number, err := parseNumber(t, value)
+		if err != nil {
+			return err
+		}
+		enum = append(enum, number)
+	}
+
+	schema.Enum = enum
+	return nil
+}
+
+// parseNumber parses the number parameter of a validate tag, or the duration parameter for [time.Duration].
+func parseNumber(t reflect.Type, param string) (float64, error) {
+	if t == durationType {
+		duration, err := time.ParseDuration(param)
+		return float64(duration), err
+	}
+	return strconv.ParseFloat(param, 64)
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isLengthKind reports whether the length of the values of type t can be documented.
+// The byte slices, documented as base64 strings, are not.
+func isLengthKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Map, reflect.Array:
+		return true
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
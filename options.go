@@ -1,6 +1,7 @@
 package fuego
 
 import (
+	"context"
 	"html/template"
 	"io"
 	"io/fs"
@@ -9,6 +10,8 @@ import (
 	"os"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/getkin/kin-openapi/openapi3gen"
@@ -27,6 +30,13 @@ type OpenAPIConfig struct {
 	PrettyFormatJson bool                              // Pretty prints the OpenAPI spec with proper JSON indentation
 }
 
+var defaultOpenAPIConfig = OpenAPIConfig{
+	SwaggerUrl:   "/swagger",
+	JsonUrl:      "/swagger/openapi.json",
+	JsonFilePath: "doc/openapi.json",
+	UIHandler:    DefaultOpenAPIHandler,
+}
+
 type RouterGroup struct {
 	rg     *gin.RouterGroup
 	server *Server
@@ -47,6 +57,7 @@ type RouterGroup struct {
 
 type Server struct {
 	// The underlying HTTP server
+	Server *http.Server
 
 	// Will be plugged into the Server field.
 	// Not using directly the Server field so
@@ -58,26 +69,59 @@ type Server struct {
 	// it applies on routes that are not registered.
 	// For example, it allows OPTIONS /foo even if it is not declared (only GET /foo is declared).
 	corsMiddleware func(http.Handler) http.Handler
+	corsConfig     *CorsConfig // CORS handling with the methods of the route registry, see [WithCors]
+
+	handler http.Handler // Engine wrapped with the CORS middleware, built on setup
 
 	disableStartupMessages bool
 	disableAutoGroupTags   bool
+	strictRoutes           bool // If true, modifying a route after it has been finalized panics instead of logging a warning
+
+	startTimeout    time.Duration                     // Deadline of the start hooks
+	shutdownTimeout time.Duration                     // Deadline of the graceful shutdown when the context of [Server.RunWithContext] is done
+	startHooks      []func(ctx context.Context) error // Run in order before listening
+	shutdownHooks   []func(ctx context.Context) error // Run in order on shutdown, after draining requests
+	shutdownOnce    sync.Once
+	shutdownDone    chan struct{} // Closed once [Server.Shutdown] has drained the requests and run the hooks
+	shutdownErr     error
+
+	routes []*Route // Registry of all the routes registered on the server, finalized before serving or exporting the spec
 
 	globalOpenAPIResponses []openAPIError // Global error responses
 
 	OpenApiSpec openapi3.T // OpenAPI spec generated by the server
 
-	Security Security
+	OpenAPIConfig OpenAPIConfig
+
+	setupOnce         sync.Once
+	openAPIRoutesOnce sync.Once
+	openAPISpecJSON   []byte // Last marshaled OpenAPI spec, served on [OpenAPIConfig.JsonUrl]
+	Security          Security
 
 	fs       fs.FS
 	template *template.Template // TODO: use preparsed templates
 
 	DisallowUnknownFields bool // If true, the server will return an error if the request body contains unknown fields. Useful for quick debugging in development.
 	maxBodySize           int64
-
-	Serialize      Sender                // Custom serializer that overrides the default one.
-	SerializeError ErrorSender           // Used to serialize the error response. Defaults to [SendError].
+	maxFileSize           int64
+	codecs                codecRegistry       // Codecs by order of preference, see [WithCodec]
+	problemTypes          problemTypeRegistry // Problem types of the errors, see [WithProblemType]
+	devMode               bool                // If true, the panic stacks are included in the error responses, see [WithDevMode]
+	panicReporter         PanicReporter       // Receives the recovered panics, see [WithPanicReporter]
+	validator             *structValidator    // Validates the request bodies and parameters, see [WithValidator] and [WithValidationRule]
+	requestValidation     bool                // If true, the requests are validated against the OpenAPI operations, see [WithRequestValidation]
+	responseValidation    bool                // If true, the responses are validated against the OpenAPI operations, see [WithResponseValidation]
+	strictResponses       bool                // If true, the invalid responses are replaced by 500 errors
+	webSocketOrigins      []string            // Origins allowed to open WebSocket connections, besides the same origin, see [WithWebSocketOrigins]
+	contentNegotiation    bool                // If true, the routes document the media types of all the codecs, see [WithContentNegotiation]
+
+	Serialize      Sender                // Custom serializer that overrides the default one. Defaults to [SendJSON].
+	SerializeError ErrorSender           // Used to serialize the error response. Defaults to [SendJSONError].
 	ErrorHandler   func(err error) error // Used to transform any error into a unified error type structure with status code. Defaults to [ErrorHandler]
 	generator      *openapi3gen.Generator
+
+	// Used instead of ErrorHandler if set, to transform the errors depending on the request. See [WithContextErrorHandler].
+	ContextErrorHandler func(ctx ContextNoBody, err error) error
 }
 
 // NewServer creates a new server with the given options.
@@ -109,10 +153,22 @@ func NewServerWithRouterGroup(rg *gin.RouterGroup, options ...func(*Server)) *Se
 	}
 
 	s := &Server{
-		OpenApiSpec: NewOpenApiSpec(),
-		Security:    NewSecurity(),
+		Server: &http.Server{
+			ReadHeaderTimeout: 30 * time.Second,
+			MaxHeaderBytes:    1 << 20,
+		},
+		codecs:          defaultCodecs,
+		problemTypes:    defaultProblemTypes,
+		validator:       newStructValidator(validator.New()),
+		startTimeout:    15 * time.Second,
+		shutdownTimeout: 30 * time.Second,
+		shutdownDone:    make(chan struct{}),
+		OpenApiSpec:     NewOpenApiSpec(),
+		OpenAPIConfig:   defaultOpenAPIConfig,
+		Security:        NewSecurity(),
 		generator: openapi3gen.NewGenerator(
 			openapi3gen.UseAllExportedFields(),
+			openapi3gen.SchemaCustomizer(customizeSchema),
 		),
 	}
 
@@ -125,6 +181,9 @@ func NewServerWithRouterGroup(rg *gin.RouterGroup, options ...func(*Server)) *Se
 		rg:     rg,
 	}
 
+	// Answer the panics of the handlers with problem responses, and make the server available to the standard middlewares.
+	rg.Use(s.recoverPanics, s.serverPrelude)
+
 	defaultOptions := [...]func(*Server){
 		WithDisallowUnknownFields(true),
 		WithSerializer(SendJSON),
@@ -160,12 +219,13 @@ func (group *RouterGroup) newRouteGroup(path string, groupOption GroupOption) *R
 	}
 
 	return &RouterGroup{
-		rg:       group.rg.Group(path),
-		server:   group.server,
-		groupTag: groupTag,
-		params:   slices.Clone(group.params),
-		tags:     slices.Clone(group.tags),
-		routeCfg: slices.Clone(group.routeCfg),
+		rg:             group.rg.Group(path),
+		server:         group.server,
+		groupTag:       groupTag,
+		params:         slices.Clone(group.params),
+		tags:           slices.Clone(group.tags),
+		routeCfg:       slices.Clone(group.routeCfg),
+		DisableOpenapi: group.DisableOpenapi,
 	}
 }
 
@@ -191,6 +251,8 @@ func WithTemplateFS(fs fs.FS) func(*Server) {
 
 // WithCorsMiddleware registers a middleware to handle CORS.
 // It is not handled like other middlewares with [Use] because it applies routes that are not registered.
+// The methods allowed by its preflight responses are its own, like the AllowedMethods of rs/cors:
+// use [WithCors] to allow the methods of the registered routes instead.
 // For example:
 //
 //	import "github.com/rs/cors"
@@ -207,6 +269,21 @@ func WithCorsMiddleware(corsMiddleware func(http.Handler) http.Handler) func(*Se
 	return func(c *Server) { c.corsMiddleware = corsMiddleware }
 }
 
+// WithCors handles CORS with the given configuration. The preflight requests are answered
+// with the methods of the routes registered for the requested path, as in the Allow header of OPTIONS requests.
+// For example:
+//
+//	s := fuego.NewServer(
+//		fuego.WithCors(fuego.CorsConfig{
+//			AllowedOrigins:   []string{"https://example.com"},
+//			AllowedHeaders:   []string{"Authorization"},
+//			AllowCredentials: true,
+//		}),
+//	)
+func WithCors(config CorsConfig) func(*Server) {
+	return func(c *Server) { c.corsConfig = &config }
+}
+
 // WithGlobalResponseTypes adds default response types to the server.
 // useful for adding global error types.
 // For example:
@@ -232,6 +309,50 @@ func WithGlobalResponseTypes(code int, errType any, description string, contentT
 	}
 }
 
+// WithOpenAPIConfig sets the OpenAPI config for the server.
+// Empty URLs, file path and UI handler keep their default values.
+// For example:
+//
+//	app := fuego.NewServer(
+//		fuego.WithOpenAPIConfig(fuego.OpenAPIConfig{
+//			JsonUrl:          "/openapi.json",
+//			SwaggerUrl:       "/docs",
+//			DisableLocalSave: true,
+//		}),
+//	)
+func WithOpenAPIConfig(openapiConfig OpenAPIConfig) func(*Server) {
+	return func(s *Server) {
+		if openapiConfig.JsonUrl != "" {
+			s.OpenAPIConfig.JsonUrl = openapiConfig.JsonUrl
+		}
+
+		if openapiConfig.SwaggerUrl != "" {
+			s.OpenAPIConfig.SwaggerUrl = openapiConfig.SwaggerUrl
+		}
+
+		if openapiConfig.JsonFilePath != "" {
+			s.OpenAPIConfig.JsonFilePath = openapiConfig.JsonFilePath
+		}
+
+		if openapiConfig.UIHandler != nil {
+			s.OpenAPIConfig.UIHandler = openapiConfig.UIHandler
+		}
+
+		s.OpenAPIConfig.DisableSwagger = openapiConfig.DisableSwagger
+		s.OpenAPIConfig.DisableSwaggerUI = openapiConfig.DisableSwaggerUI
+		s.OpenAPIConfig.DisableLocalSave = openapiConfig.DisableLocalSave
+		s.OpenAPIConfig.PrettyFormatJson = openapiConfig.PrettyFormatJson
+
+		if !validateJsonSpecUrl(s.OpenAPIConfig.JsonUrl) {
+			panic("invalid OpenAPI JSON spec URL, it must start with '/' and end with '.json': " + s.OpenAPIConfig.JsonUrl)
+		}
+
+		if !validateSwaggerUrl(s.OpenAPIConfig.SwaggerUrl) {
+			panic("invalid OpenAPI UI URL, it must start with '/' and not end with '/': " + s.OpenAPIConfig.SwaggerUrl)
+		}
+	}
+}
+
 // WithoutAutoGroupTags disables the automatic grouping of routes by tags.
 // By default, routes are tagged by group.
 // For example:
@@ -246,6 +367,14 @@ func WithoutAutoGroupTags() func(*Server) {
 	return func(c *Server) { c.disableAutoGroupTags = true }
 }
 
+// WithStrictRoutes makes the server panic when a route is modified after being finalized.
+// Routes are finalized (group configurations applied and OpenAPI operation generated)
+// when the server starts or when the OpenAPI spec is output.
+// Without this option, such modifications only log a warning.
+func WithStrictRoutes() func(*Server) {
+	return func(c *Server) { c.strictRoutes = true }
+}
+
 // WithTemplates loads the templates used to render HTML.
 // To be used with [WithTemplateFS]. If not set, it will use the os filesystem, at folder "./templates".
 func WithTemplates(templates *template.Template) func(*Server) {
@@ -284,10 +413,110 @@ func WithTemplateGlobs(patterns ...string) func(*Server) {
 	}
 }
 
+// WithReadTimeout sets the maximum duration for reading the entire request, including the body.
+// Defaults to no timeout.
+func WithReadTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.Server.ReadTimeout = timeout }
+}
+
+// WithReadHeaderTimeout sets the maximum duration for reading the request headers.
+// Defaults to 30 seconds.
+func WithReadHeaderTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.Server.ReadHeaderTimeout = timeout }
+}
+
+// WithWriteTimeout sets the maximum duration before timing out writes of the response.
+// Defaults to no timeout.
+func WithWriteTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.Server.WriteTimeout = timeout }
+}
+
+// WithIdleTimeout sets the maximum amount of time to wait for the next request when keep-alives are enabled.
+// Defaults to the read timeout.
+func WithIdleTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.Server.IdleTimeout = timeout }
+}
+
+// WithStartTimeout sets the deadline of the [Server.OnStart] hooks.
+// Defaults to 15 seconds.
+func WithStartTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.startTimeout = timeout }
+}
+
+// WithShutdownTimeout sets the time given to drain in-flight requests and run the [Server.OnShutdown] hooks
+// when the context of [Server.RunWithContext] is done.
+// Defaults to 30 seconds.
+func WithShutdownTimeout(timeout time.Duration) func(*Server) {
+	return func(c *Server) { c.shutdownTimeout = timeout }
+}
+
 func WithMaxBodySize(maxBodySize int64) func(*Server) {
 	return func(c *Server) { c.maxBodySize = maxBodySize }
 }
 
+// WithMaxFileSize sets the maximum size of each file uploaded in a multipart/form-data request body.
+// Larger files are rejected with a 413 Payload Too Large error.
+// The whole request body is still limited by [WithMaxBodySize].
+func WithMaxFileSize(maxFileSize int64) func(*Server) {
+	return func(c *Server) { c.maxFileSize = maxFileSize }
+}
+
+// WithWebSocketOrigins allows WebSocket handshakes from the given origins, like "https://app.example.com".
+// By default, the browsers can only open WebSocket connections from the same origin as the server,
+// to protect from cross-site WebSocket hijacking. Use "*" to allow any origin.
+func WithWebSocketOrigins(origins ...string) func(*Server) {
+	return func(c *Server) { c.webSocketOrigins = append(c.webSocketOrigins, origins...) }
+}
+
+// WithCodec registers a codec, for decoding the request bodies and encoding the responses in its media types.
+// It takes precedence over the codecs already registered for the same media types, including the default ones:
+// JSON, XML, YAML, MessagePack, CBOR, HTML, text, forms and binary.
+// For example:
+//
+//	app := fuego.NewServer(
+//		fuego.WithCodec(MyProtobufCodec{}),
+//	)
+func WithCodec(codec Codec) func(*Server) {
+	return func(c *Server) { c.codecs = c.codecs.with(codec) }
+}
+
+// WithProblemType registers the problem type of the errors of the same type as err.
+// It sets the type and title of their problem details (RFC 9457), when they are empty.
+// The status, title and type default to the ones of the status code of err.
+// The problem types are exported in the x-problem-types extension of the OpenAPI components.
+// For example:
+//
+//	type OutOfStockError fuego.HTTPError // With Error, StatusCode and Unwrap methods
+//
+//	app := fuego.NewServer(
+//		fuego.WithProblemType(OutOfStockError{}, fuego.ProblemType{
+//			Type:        "https://example.com/problems/out-of-stock",
+//			Title:       "Out of stock",
+//			Description: "The product is not available anymore.",
+//		}),
+//	)
+func WithProblemType(err error, problemType ProblemType) func(*Server) {
+	return func(c *Server) { c.problemTypes = c.problemTypes.with(err, problemType) }
+}
+
+// WithDevMode includes the stack of the recovered panics in the detail of the 500 error responses.
+// Never enable it in production, as the stacks expose the internals of the application.
+func WithDevMode(devMode bool) func(*Server) {
+	return func(c *Server) { c.devMode = devMode }
+}
+
+// WithPanicReporter sets the reporter receiving the panics recovered by the server, with their stack.
+// For example, to forward them to an error reporting service:
+//
+//	app := fuego.NewServer(
+//		fuego.WithPanicReporter(fuego.PanicReporterFunc(func(r *http.Request, recovered any, stack []byte) {
+//			errorReporter.Capture(r.Context(), recovered, stack)
+//		})),
+//	)
+func WithPanicReporter(reporter PanicReporter) func(*Server) {
+	return func(c *Server) { c.panicReporter = reporter }
+}
+
 // WithDisallowUnknownFields sets the DisallowUnknownFields option.
 // If true, the server will return an error if the request body contains unknown fields.
 // Useful for quick debugging in development.
@@ -315,6 +544,18 @@ func WithLogHandler(handler slog.Handler) func(*Server) {
 	}
 }
 
+// WithContentNegotiation negotiates the format of the responses and of the errors with the Accept header,
+// among the media types of the codecs (see [WithCodec]), with [Send] and [SendError].
+// The routes not declaring the content types of their response with [Route.WithResponse] document all of them.
+// By default, the responses and the errors are sent as JSON, with [SendJSON] and [SendJSONError].
+func WithContentNegotiation() func(*Server) {
+	return func(c *Server) {
+		c.Serialize = Send
+		c.SerializeError = SendError
+		c.contentNegotiation = true
+	}
+}
+
 // WithSerializer sets a custom serializer of type Sender that overrides the default one.
 // Please send a PR if you think the default serializer should be improved, instead of jumping to this option.
 func WithSerializer(serializer Sender) func(*Server) {
@@ -331,6 +572,21 @@ func WithErrorHandler(errorHandler func(err error) error) func(*Server) {
 	return func(c *Server) { c.ErrorHandler = errorHandler }
 }
 
+// WithContextErrorHandler sets an error handler with access to the request, used instead of the one of [WithErrorHandler].
+// It transforms the errors of the controllers, and the ones sent by the middlewares with [HandleError].
+// For example, to identify the occurrence of the problem with the request path:
+//
+//	app := fuego.NewServer(
+//		fuego.WithContextErrorHandler(func(ctx fuego.ContextNoBody, err error) error {
+//			httpError := fuego.ErrorHandler(err).(fuego.HTTPError)
+//			httpError.Instance = ctx.Request().URL.Path
+//			return httpError
+//		}),
+//	)
+func WithContextErrorHandler(errorHandler func(ctx ContextNoBody, err error) error) func(*Server) {
+	return func(c *Server) { c.ContextErrorHandler = errorHandler }
+}
+
 // WithoutStartupMessages disables the startup message
 func WithoutStartupMessages() func(*Server) {
 	return func(c *Server) { c.disableStartupMessages = true }
@@ -343,7 +599,8 @@ func WithoutLogger() func(*Server) {
 	}
 }
 
-// WithValidator sets the validator to be used by the fuego server.
+// WithValidator sets the validator to be used by the fuego server, keeping the rules of [WithValidationRule].
+// Each server has its own validator, so servers of the same process can use different rules.
 // If no validator is provided, a default validator will be used.
 //
 // Note: If you are using the default validator, you can add tags to your structs using the `validate` tag.
@@ -361,7 +618,65 @@ func WithValidator(newValidator *validator.Validate) func(*Server) {
 	}
 
 	return func(s *Server) {
-		v = newValidator
+		s.validator = s.validator.withValidate(newValidator)
+	}
+}
+
+// WithValidationRule registers a custom validation tag on the validator of the server.
+// The rule can translate its messages and document its effect on the OpenAPI schema of the fields using it.
+// For example:
+//
+//	app := fuego.NewServer(
+//		fuego.WithValidationRule(fuego.ValidationRule{
+//			Tag: "sku",
+//			Func: func(fl validator.FieldLevel) bool {
+//				return skuRegexp.MatchString(fl.Field().String())
+//			},
+//			Messages: map[string]string{
+//				"en": "{0} should be a valid SKU",
+//				"fr": "{0} doit être un SKU valide",
+//			},
+//			Schema: func(schema *openapi3.Schema, _ string) {
+//				schema.Pattern = skuRegexp.String()
+//			},
+//		}),
+//	)
+func WithValidationRule(rule ValidationRule) func(*Server) {
+	if rule.Tag == "" || rule.Func == nil {
+		panic("validation rule must have a tag and a function")
+	}
+
+	return func(s *Server) {
+		s.validator.withRule(rule)
+	}
+}
+
+// WithRequestValidation validates the requests against the OpenAPI operations of their routes before the controllers,
+// with kin-openapi's openapi3filter: the parameters, the content type and the body schema.
+// The invalid requests are answered with a [BadRequestError] listing the invalid fields.
+// It also validates the requests of the routes registered with [GetGin] and the other gin handlers,
+// when their body is documented with [Route.WithRequest].
+// The bodies are read in memory to be validated, within the limit of [WithMaxBodySize].
+// The forms, like the file uploads, are not read by the request validation: they are validated by the controllers.
+func WithRequestValidation() func(*Server) {
+	return func(s *Server) { s.requestValidation = true }
+}
+
+// WithResponseValidation validates the responses of the controllers against the OpenAPI operations of their routes:
+// the documented headers, and the body schema of the response for the returned status and content type.
+// It catches the drift between the controllers and the documented responses, in development and in tests.
+// The mismatches are logged as warnings. In strict mode, the invalid responses are replaced by 500 errors,
+// with the mismatches in their detail. Never enable it in production: the responses are buffered to be validated,
+// and the strict mode exposes the internals of the application. The servers without this option are not affected.
+// For example, in the tests:
+//
+//	app := fuego.NewServer(
+//		fuego.WithResponseValidation(true),
+//	)
+func WithResponseValidation(strict bool) func(*Server) {
+	return func(s *Server) {
+		s.responseValidation = true
+		s.strictResponses = strict
 	}
 }
 
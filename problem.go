@@ -0,0 +1,260 @@
+package fuego
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// ProblemType documents a kind of error, identified by its Type URI in the problem details (RFC 9457).
+// The problem types are registered with [WithProblemType], and exported in the x-problem-types extension
+// of the OpenAPI components.
+type ProblemType struct {
+	Type        string `json:"type"`                  // URI identifying the problem type.
+	Title       string `json:"title,omitempty"`       // Short summary of the problem type. Defaults to the status text.
+	Status      int    `json:"status,omitempty"`      // Status code of the problem type.
+	Description string `json:"description,omitempty"` // Longer documentation of the problem type, only exported in the OpenAPI spec.
+}
+
+// problemTypeEntry is a problem type, with the error type it is registered for.
+type problemTypeEntry struct {
+	kind        reflect.Type
+	problemType ProblemType
+}
+
+// problemTypeRegistry is the list of the problem types of a server.
+type problemTypeRegistry []problemTypeEntry
+
+// defaultProblemTypes are the problem types of the errors of Fuego, documented by the HTTP semantics RFC.
+var defaultProblemTypes = problemTypeRegistry{}.
+	with(BadRequestError{}, ProblemType{}).
+	with(UnauthorizedError{}, ProblemType{}).
+	with(ForbiddenError{}, ProblemType{}).
+	with(NotFoundError{}, ProblemType{}).
+	with(ConflictError{}, ProblemType{}).
+	with(NotAcceptableError{}, ProblemType{}).
+	with(MethodNotAllowedError{}, ProblemType{}).
+	with(UnsupportedMediaTypeError{}, ProblemType{}).
+	with(UnprocessableEntityError{}, ProblemType{}).
+	with(TooManyRequestsError{}, ProblemType{}).
+	with(ServiceUnavailableError{}, ProblemType{})
+
+// rfc9110Sections are the sections of RFC 9110 defining the error status codes.
+var rfc9110Sections = map[int]string{
+	http.StatusBadRequest:                   "15.5.1",
+	http.StatusUnauthorized:                 "15.5.2",
+	http.StatusPaymentRequired:              "15.5.3",
+	http.StatusForbidden:                    "15.5.4",
+	http.StatusNotFound:                     "15.5.5",
+	http.StatusMethodNotAllowed:             "15.5.6",
+	http.StatusNotAcceptable:                "15.5.7",
+	http.StatusProxyAuthRequired:            "15.5.8",
+	http.StatusRequestTimeout:               "15.5.9",
+	http.StatusConflict:                     "15.5.10",
+	http.StatusGone:                         "15.5.11",
+	http.StatusLengthRequired:               "15.5.12",
+	http.StatusPreconditionFailed:           "15.5.13",
+	http.StatusRequestEntityTooLarge:        "15.5.14",
+	http.StatusRequestURITooLong:            "15.5.15",
+	http.StatusUnsupportedMediaType:         "15.5.16",
+	http.StatusRequestedRangeNotSatisfiable: "15.5.17",
+	http.StatusExpectationFailed:            "15.5.18",
+	http.StatusMisdirectedRequest:           "15.5.20",
+	http.StatusUnprocessableEntity:          "15.5.21",
+	http.StatusUpgradeRequired:              "15.5.22",
+	http.StatusInternalServerError:          "15.6.1",
+	http.StatusNotImplemented:               "15.6.2",
+	http.StatusBadGateway:                   "15.6.3",
+	http.StatusServiceUnavailable:           "15.6.4",
+	http.StatusGatewayTimeout:               "15.6.5",
+	http.StatusHTTPVersionNotSupported:      "15.6.6",
+}
+
+// statusProblemType returns the URI of the RFC 9110 section defining the status code,
+// or about:blank if it is not defined there (RFC 9457 section 4.2.1).
+func statusProblemType(status int) string {
+	section, ok := rfc9110Sections[status]
+	if !ok {
+		return "about:blank"
+	}
+	return "https://www.rfc-editor.org/rfc/rfc9110#section-" + section
+}
+
+// with returns a new registry with the problem type of the errors of the same type as err.
+// The status, title and type default to the ones of the status code of err.
+func (registry problemTypeRegistry) with(err error, problemType ProblemType) problemTypeRegistry {
+	if problemType.Status == 0 {
+		problemType.Status = errorStatus(err)
+	}
+	if problemType.Title == "" {
+		problemType.Title = http.StatusText(problemType.Status)
+	}
+	if problemType.Type == "" {
+		problemType.Type = statusProblemType(problemType.Status)
+	}
+
+	kind := reflect.TypeOf(err)
+	registry = slices.DeleteFunc(slices.Clone(registry), func(entry problemTypeEntry) bool { return entry.kind == kind })
+	return append(registry, problemTypeEntry{kind: kind, problemType: problemType})
+}
+
+// lookup returns the problem type of the first error of the chain with a registered type.
+func (registry problemTypeRegistry) lookup(err error) (ProblemType, bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		kind := reflect.TypeOf(err)
+		for _, entry := range registry {
+			if entry.kind == kind {
+				return entry.problemType, true
+			}
+		}
+	}
+	return ProblemType{}, false
+}
+
+// openAPIExtension returns the problem types by error type name, for the x-problem-types extension.
+func (registry problemTypeRegistry) openAPIExtension() map[string]ProblemType {
+	problemTypes := make(map[string]ProblemType, len(registry))
+	for _, entry := range registry {
+		problemTypes[entry.kind.Name()] = entry.problemType
+	}
+	return problemTypes
+}
+
+// documentProblemTypes exports the problem types in the x-problem-types extension of the OpenAPI components.
+func (s *Server) documentProblemTypes() {
+	if s.OpenApiSpec.Components == nil {
+		s.OpenApiSpec.Components = &openapi3.Components{}
+	}
+	if s.OpenApiSpec.Components.Extensions == nil {
+		s.OpenApiSpec.Components.Extensions = map[string]any{}
+	}
+	s.OpenApiSpec.Components.Extensions["x-problem-types"] = s.problemTypes.openAPIExtension()
+}
+
+// problemTypesFromRequest returns the problem types of the server handling the request.
+func problemTypesFromRequest(r *http.Request) problemTypeRegistry {
+	if s := serverFromRequest(r); s != nil && s.problemTypes != nil {
+		return s.problemTypes
+	}
+	return defaultProblemTypes
+}
+
+// problemOf returns the problem details document (RFC 9457) of the error, serialized by all the error senders.
+// The status comes from [ErrorWithStatus], the type and title default to the ones of the registered
+// problem type of the error, or of its status code. The underlying error is never exposed.
+func problemOf(r *http.Request, err error) HTTPError {
+	var problem HTTPError
+	errors.As(err, &problem)
+	problem.Err = err
+
+	problem.Status = errorStatus(err)
+
+	problemType, ok := problemTypesFromRequest(r).lookup(err)
+	if !ok {
+		problemType = ProblemType{Type: statusProblemType(problem.Status), Title: http.StatusText(problem.Status)}
+	}
+	if problem.Type == "" {
+		problem.Type = problemType.Type
+	}
+	if problem.Title == "" {
+		problem.Title = problemType.Title
+	}
+
+	return problem
+}
+
+// xmlProblem is the XML representation of the problem details (RFC 9457 appendix B).
+type xmlProblem struct {
+	XMLName  xml.Name       `xml:"urn:ietf:rfc:7807 problem"`
+	Type     string         `xml:"type,omitempty"`
+	Title    string         `xml:"title,omitempty"`
+	Status   int            `xml:"status,omitempty"`
+	Detail   string         `xml:"detail,omitempty"`
+	Instance string         `xml:"instance,omitempty"`
+	Errors   []xmlErrorItem `xml:"errors>i,omitempty"`
+}
+
+type xmlErrorItem struct {
+	Name    string       `xml:"name"`
+	Reason  string       `xml:"reason"`
+	Pointer string       `xml:"pointer,omitempty"`
+	In      string       `xml:"in,omitempty"`
+	More    []xmlMoreKey `xml:"more>entry,omitempty"`
+}
+
+type xmlMoreKey struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// MarshalXML writes the error as a problem details XML document, with the `more` maps of the items as entries.
+func (e HTTPError) MarshalXML(enc *xml.Encoder, _ xml.StartElement) error {
+	problem := xmlProblem{
+		Type:     e.Type,
+		Title:    e.Title,
+		Status:   e.Status,
+		Detail:   e.Detail,
+		Instance: e.Instance,
+	}
+	for _, item := range e.Errors {
+		xmlItem := xmlErrorItem{Name: item.Name, Reason: item.Reason, Pointer: item.Pointer, In: item.In}
+		for key, value := range item.More {
+			xmlItem.More = append(xmlItem.More, xmlMoreKey{Key: key, Value: fmt.Sprint(value)})
+		}
+		slices.SortFunc(xmlItem.More, func(a, b xmlMoreKey) int { return strings.Compare(a.Key, b.Key) })
+		problem.Errors = append(problem.Errors, xmlItem)
+	}
+
+	return enc.Encode(problem)
+}
+
+// writeText writes the error as a plain text problem details document.
+func (e HTTPError) writeText(w io.Writer) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s (%d)\n", e.Title, e.Status)
+	if e.Detail != "" {
+		fmt.Fprintf(&text, "\n%s\n", e.Detail)
+	}
+	if len(e.Errors) > 0 {
+		text.WriteString("\n")
+		for _, item := range e.Errors {
+			fmt.Fprintf(&text, "- %s: %s\n", item.Name, item.Reason)
+		}
+	}
+	fmt.Fprintf(&text, "\ntype: %s\n", e.Type)
+	if e.Instance != "" {
+		fmt.Fprintf(&text, "instance: %s\n", e.Instance)
+	}
+
+	_, err := io.WriteString(w, text.String())
+	return err
+}
+
+// problemHTMLTemplate is the HTML problem details document.
+var problemHTMLTemplate = template.Must(template.New("problem").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body>
+<h1>{{.Title}} ({{.Status}})</h1>
+{{if .Detail}}<p>{{.Detail}}</p>
+{{end}}{{if .Errors}}<ul>
+{{range .Errors}}<li><strong>{{.Name}}</strong>: {{.Reason}}</li>
+{{end}}</ul>
+{{end}}<p>Type: <a href="{{.Type}}">{{.Type}}</a></p>
+{{if .Instance}}<p>Instance: {{.Instance}}</p>
+{{end}}</body>
+</html>
+`))
+
+// writeHTML writes the error as a HTML problem details document, escaping all its fields.
+func (e HTTPError) writeHTML(w io.Writer) error {
+	return problemHTMLTemplate.Execute(w, e)
+}
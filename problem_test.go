@@ -0,0 +1,133 @@
+package fuego
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type outOfStockError struct{}
+
+func (outOfStockError) Error() string   { return "out of stock" }
+func (outOfStockError) StatusCode() int { return http.StatusConflict }
+
+func TestProblemDetails(t *testing.T) {
+	s := NewServer(
+		WithoutLogger(),
+		WithErrorSerializer(SendError),
+		WithProblemType(outOfStockError{}, ProblemType{
+			Type:        "https://example.com/problems/out-of-stock",
+			Title:       "Out of stock",
+			Description: "The product is not available anymore.",
+		}),
+	)
+
+	Get(s.RouterGroup(), "/missing", func(c ContextNoBody) (any, error) {
+		return nil, NotFoundError{
+			Detail: "no <b>recipe</b> 42",
+			Err:    http.ErrNoLocation,
+			Errors: []ErrorItem{{Name: "id", Reason: "unknown", More: map[string]any{"in": "path", "value": 42}}},
+		}
+	})
+
+	Get(s.RouterGroup(), "/stock", func(c ContextNoBody) (any, error) {
+		return nil, outOfStockError{}
+	})
+
+	send := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("Accept", accept)
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	notFoundType := "https://www.rfc-editor.org/rfc/rfc9110#section-15.5.5"
+
+	t.Run("JSON", func(t *testing.T) {
+		w := send("/missing", "application/json")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		require.JSONEq(t, `{
+			"type": "`+notFoundType+`",
+			"title": "Not Found",
+			"status": 404,
+			"detail": "no <b>recipe</b> 42",
+			"errors": [{"name": "id", "reason": "unknown", "more": {"in": "path", "value": 42}}]
+		}`, w.Body.String())
+	})
+
+	t.Run("XML", func(t *testing.T) {
+		w := send("/missing", "application/problem+xml")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "application/problem+xml", w.Header().Get("Content-Type"))
+		require.Equal(t, `<problem xmlns="urn:ietf:rfc:7807">`+
+			`<type>`+notFoundType+`</type><title>Not Found</title><status>404</status><detail>no &lt;b&gt;recipe&lt;/b&gt; 42</detail>`+
+			`<errors><i><name>id</name><reason>unknown</reason><more><entry key="in">path</entry><entry key="value">42</entry></more></i></errors>`+
+			`</problem>`, w.Body.String())
+	})
+
+	t.Run("YAML", func(t *testing.T) {
+		w := send("/missing", "application/x-yaml")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		var problem map[string]any
+		require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &problem))
+		require.Equal(t, notFoundType, problem["type"])
+		require.Equal(t, "Not Found", problem["title"])
+		require.Equal(t, 404, problem["status"])
+		require.Equal(t, "no <b>recipe</b> 42", problem["detail"])
+		require.NotContains(t, problem, "err")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		w := send("/missing", "text/html")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "<h1>Not Found (404)</h1>")
+		require.Contains(t, w.Body.String(), "<p>no &lt;b&gt;recipe&lt;/b&gt; 42</p>")
+		require.Contains(t, w.Body.String(), "<li><strong>id</strong>: unknown</li>")
+		require.Contains(t, w.Body.String(), `<a href="`+notFoundType+`">`)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		w := send("/missing", "text/plain")
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+		require.Equal(t, "Not Found (404)\n\nno <b>recipe</b> 42\n\n- id: unknown\n\ntype: "+notFoundType+"\n", w.Body.String())
+	})
+
+	t.Run("registered problem types", func(t *testing.T) {
+		w := send("/stock", "application/json")
+
+		require.Equal(t, http.StatusConflict, w.Code)
+		require.JSONEq(t, `{"type":"https://example.com/problems/out-of-stock","title":"Out of stock","status":409}`, w.Body.String())
+	})
+
+	t.Run("exports the problem types", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		spec, err := json.Marshal(s.OpenApiSpec.Components)
+		require.NoError(t, err)
+
+		var components struct {
+			ProblemTypes map[string]ProblemType `json:"x-problem-types"`
+		}
+		require.NoError(t, json.Unmarshal(spec, &components))
+		require.Equal(t, ProblemType{
+			Type:        "https://example.com/problems/out-of-stock",
+			Title:       "Out of stock",
+			Status:      http.StatusConflict,
+			Description: "The product is not available anymore.",
+		}, components.ProblemTypes["outOfStockError"])
+		require.Equal(t, notFoundType, components.ProblemTypes["NotFoundError"].Type)
+	})
+}
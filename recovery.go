@@ -0,0 +1,76 @@
+package fuego
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PanicReporter receives the panics recovered by the server, to forward them to an error reporting service.
+// It is called before the error response is written. See [WithPanicReporter].
+type PanicReporter interface {
+	ReportPanic(r *http.Request, recovered any, stack []byte)
+}
+
+// PanicReporterFunc is a function implementing [PanicReporter].
+type PanicReporterFunc func(r *http.Request, recovered any, stack []byte)
+
+func (f PanicReporterFunc) ReportPanic(r *http.Request, recovered any, stack []byte) {
+	f(r, recovered, stack)
+}
+
+// recoverPanics is the middleware recovering the panics of the handlers, like the ones of [ContextWithBody.MustBody].
+// The panic is logged with its stack, reported, then answered with a 500 [HTTPError]
+// through the error handler and serializer of the server.
+// If the response is already partially written, the connection is aborted with [http.ErrAbortHandler] instead,
+// so the client does not mistake the truncated response for the whole one.
+// [http.ErrAbortHandler] is panicked again, to abort the response as net/http does.
+func (s *Server) recoverPanics(c *gin.Context) {
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		if recovered == http.ErrAbortHandler {
+			panic(recovered)
+		}
+
+		stack := debug.Stack()
+		slog.Error("Panic recovered", "method", c.Request.Method, "path", c.Request.URL.Path, "panic", recovered, "stack", string(stack))
+		if s.panicReporter != nil {
+			s.panicReporter.ReportPanic(c.Request, recovered, stack)
+		}
+
+		c.Abort()
+		if c.Writer.Written() {
+			panic(http.ErrAbortHandler)
+		}
+		s.serializeError(c.Writer, c.Request, panicError(recovered, stack, s.devMode))
+	}()
+
+	c.Next()
+}
+
+// panicError returns the 500 error answering a panic. The stack is only exposed in dev mode.
+func panicError(recovered any, stack []byte, devMode bool) HTTPError {
+	err, ok := recovered.(error)
+	if ok {
+		err = fmt.Errorf("panic: %w", err)
+	} else {
+		err = errors.New("panic: " + fmt.Sprint(recovered))
+	}
+
+	httpError := HTTPError{
+		Err:    err,
+		Status: http.StatusInternalServerError,
+	}
+	if devMode {
+		httpError.Detail = err.Error() + "\n\n" + string(stack)
+	}
+
+	return httpError
+}
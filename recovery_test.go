@@ -0,0 +1,96 @@
+package fuego
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	newServer := func(options ...func(*Server)) *Server {
+		s := NewServer(append([]func(*Server){WithoutLogger()}, options...)...)
+
+		Post(s.RouterGroup(), "/must-body", func(c *ContextWithBody[MyStruct]) (MyStruct, error) {
+			return c.MustBody(), nil
+		})
+
+		Get(s.RouterGroup(), "/panic", func(c ContextNoBody) (any, error) {
+			panic(errors.New("boom"))
+		})
+
+		Get(s.RouterGroup(), "/written", func(c ContextNoBody) (any, error) {
+			c.Response().WriteHeader(http.StatusAccepted)
+			_, _ = c.Response().Write([]byte("partial"))
+			panic("boom")
+		})
+
+		return s
+	}
+
+	t.Run("answers panics with a problem response", func(t *testing.T) {
+		s := newServer()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/must-body", strings.NewReader(`{"b":`))
+		r.Header.Set("Content-Type", "application/json")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+		require.NotContains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("includes the stack in dev mode", func(t *testing.T) {
+		s := newServer(WithDevMode(true))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.Contains(t, w.Body.String(), "panic: boom")
+		require.Contains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("reports panics", func(t *testing.T) {
+		var reported any
+		var reportedStack []byte
+		s := newServer(WithPanicReporter(PanicReporterFunc(func(r *http.Request, recovered any, stack []byte) {
+			reported = recovered
+			reportedStack = stack
+		})))
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/panic", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+		require.EqualError(t, reported.(error), "boom")
+		require.NotEmpty(t, reportedStack)
+	})
+
+	t.Run("aborts the connection once the response is written", func(t *testing.T) {
+		s := newServer()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/written", nil)
+		require.PanicsWithValue(t, http.ErrAbortHandler, func() { s.ServeHTTP(w, r) })
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.Equal(t, "partial", w.Body.String(), "no problem response is appended")
+
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		res, err := http.Get(server.URL + "/written")
+		if err == nil {
+			_, err = io.ReadAll(res.Body)
+			res.Body.Close()
+		}
+		require.Error(t, err, "the truncated response is not mistaken for the whole one")
+	})
+}
@@ -0,0 +1,57 @@
+package fuego
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+)
+
+// Response is a controller return type carrying the status code and the headers of the response with its data.
+// The data is serialized like any other controller return value. For example:
+//
+//	fuego.Post(s.RouterGroup(), "/recipes", func(c *fuego.ContextWithBody[Recipe]) (fuego.Response[Recipe], error) {
+//		recipe := create(c.MustBody())
+//		return fuego.Response[Recipe]{
+//			Status:  http.StatusCreated,
+//			Headers: http.Header{"Location": {"/recipes/" + recipe.ID}},
+//			Data:    recipe,
+//		}, nil
+//	}).Status(http.StatusCreated).ResponseHeader("Location", "URL of the created recipe")
+//
+// The route is documented with the schema of T, under the status declared with [Route.Status], or 200.
+// The status code and the headers returned at runtime are not documented in the OpenAPI spec:
+// declare the status and headers of the successful responses with [Route.Status] and [Route.ResponseHeader].
+type Response[T any] struct {
+	Status  int         // Status code of the response. If 0, the status declared with [Route.Status] is used, or 200.
+	Headers http.Header // Headers added to the response.
+	Data    T           // Data serialized in the response body.
+}
+
+// responseEnvelope is implemented by the controller return types carrying the status and headers with the data,
+// like [Response].
+type responseEnvelope interface {
+	// writeHeaders sets the headers and the status of the response, and returns the transformed data to serialize.
+	writeHeaders(ctx context.Context, w http.ResponseWriter) (any, error)
+}
+
+func (r Response[T]) describeResponse() (any, string) {
+	return *new(T), ""
+}
+
+func (r Response[T]) writeHeaders(ctx context.Context, w http.ResponseWriter) (any, error) {
+	for name, values := range r.Headers {
+		for _, value := range values {
+			w.Header().Add(name, value)
+		}
+	}
+
+	if r.Status != 0 {
+		w.WriteHeader(r.Status)
+	}
+
+	if reflect.TypeOf(r.Data) == nil {
+		return nil, nil
+	}
+
+	return transformOut(ctx, r.Data)
+}
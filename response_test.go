@@ -0,0 +1,124 @@
+package fuego
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponse(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Post(s.RouterGroup(), "/recipes", func(c *ContextWithBody[MyStruct]) (Response[MyStruct], error) {
+		body, err := c.Body()
+		if err != nil {
+			return Response[MyStruct]{}, err
+		}
+		return Response[MyStruct]{
+			Headers: http.Header{"Location": {"/recipes/" + body.B}},
+			Data:    body,
+		}, nil
+	}).
+		Status(http.StatusCreated).
+		ResponseHeader("Location", "URL of the created recipe")
+
+	Get(s.RouterGroup(), "/accepted", func(c ContextNoBody) (Response[MyStruct], error) {
+		return Response[MyStruct]{Status: http.StatusAccepted, Data: MyStruct{B: "later"}}, nil
+	})
+
+	Delete(s.RouterGroup(), "/recipes/:id", func(c ContextNoBody) (any, error) {
+		return nil, nil
+	}).Status(http.StatusNoContent)
+
+	Put(s.RouterGroup(), "/recipes/:id", func(c ContextNoBody) (MyStruct, error) {
+		return MyStruct{}, BadRequestError{Err: errors.New("always bad")}
+	}).Status(http.StatusCreated)
+
+	Patch(s.RouterGroup(), "/recipes/:id", func(c ContextNoBody) (MyStruct, error) {
+		c.SetStatus(http.StatusAccepted)
+		return MyStruct{B: "later"}, nil
+	}).Status(http.StatusCreated)
+
+	t.Run("uses the declared status and the returned headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/recipes", strings.NewReader(`{"b":"pizza"}`))
+		r.Header.Set("Content-Type", "application/json")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusCreated, w.Code)
+		require.Equal(t, "/recipes/pizza", w.Header().Get("Location"))
+		require.JSONEq(t, `{"b":"pizza","c":0,"d":false}`, w.Body.String())
+	})
+
+	t.Run("returned status overrides the default one", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/accepted", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.JSONEq(t, `{"b":"later","c":0,"d":false}`, w.Body.String())
+	})
+
+	t.Run("declared status without body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodDelete, "/recipes/1", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Empty(t, w.Body.String())
+	})
+
+	t.Run("errors keep their status", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/recipes/1", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("status set by the controller overrides the default one", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPatch, "/recipes/1", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+	})
+
+	t.Run("default status is not sent with errors", func(t *testing.T) {
+		s := NewServer(WithoutLogger(), WithErrorSerializer(func(w http.ResponseWriter, r *http.Request, err error) {
+			_, _ = w.Write([]byte(err.Error()))
+		}))
+		Post(s.RouterGroup(), "/recipes", func(c ContextNoBody) (MyStruct, error) {
+			return MyStruct{}, errors.New("not created")
+		}).Status(http.StatusCreated)
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/recipes", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "Internal Server Error")
+	})
+
+	t.Run("documents the status and headers", func(t *testing.T) {
+		post := s.OpenApiSpec.Paths.Find("/recipes").Post
+		require.Nil(t, post.Responses.Status(http.StatusOK))
+		created := post.Responses.Status(http.StatusCreated)
+		require.NotNil(t, created)
+		require.Equal(t, "Created", *created.Value.Description)
+		require.Equal(t, "#/components/schemas/MyStruct", created.Value.Content.Get("application/json").Schema.Ref)
+		require.Equal(t, "URL of the created recipe", created.Value.Headers["Location"].Value.Description)
+
+		accepted := s.OpenApiSpec.Paths.Find("/accepted").Get.Responses.Status(http.StatusOK)
+		require.NotNil(t, accepted)
+		require.Equal(t, "#/components/schemas/MyStruct", accepted.Value.Content.Get("application/json").Schema.Ref)
+
+		noContent := s.OpenApiSpec.Paths.Find("/recipes/{id}").Delete.Responses.Status(http.StatusNoContent)
+		require.NotNil(t, noContent)
+		require.Nil(t, noContent.Value.Content)
+	})
+}
@@ -0,0 +1,210 @@
+package fuego
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// sequenceFlushInterval is the maximum time the items of a streamed sequence are buffered before being flushed.
+const sequenceFlushInterval = 100 * time.Millisecond
+
+// sequenceMediaTypes are the media types sequences are streamed as, by order of preference:
+// an incrementally written JSON array, or newline delimited JSON.
+var sequenceMediaTypes = []string{"application/json", "application/x-ndjson"}
+
+// sequenceElem returns the item type of the iter.Seq[T] and channel types, readable by [sequence].
+func sequenceElem(t reflect.Type) (reflect.Type, bool) {
+	if t == nil {
+		return nil, false
+	}
+
+	switch t.Kind() {
+	case reflect.Chan:
+		if t.ChanDir()&reflect.RecvDir != 0 {
+			return t.Elem(), true
+		}
+	case reflect.Func:
+		if t.NumIn() != 1 || t.NumOut() != 0 || t.IsVariadic() {
+			return nil, false
+		}
+		yield := t.In(0)
+		if yield.Kind() == reflect.Func && yield.NumIn() == 1 && yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool {
+			return yield.In(0), true
+		}
+	}
+
+	return nil, false
+}
+
+// sequenceEncoder is implemented by the codecs able to stream a sequence themselves, like [CSVCodec].
+// With [WithContentNegotiation], the sequences are also negotiated to the media types of these codecs.
+type sequenceEncoder interface {
+	encodeSequence(w http.ResponseWriter, r *http.Request, seq sequence) error
+}
+
+// sequence streams the items of a controller returning an iter.Seq[T] or a channel of T,
+// instead of encoding a whole slice at once.
+// It is negotiated as a JSON array or as NDJSON, and documented as an array of T.
+type sequence struct {
+	items reflect.Value
+}
+
+// sequenceOf returns the sequence of the value if it is an iter.Seq[T] or a channel.
+func sequenceOf(v any) (sequence, bool) {
+	_, ok := sequenceElem(reflect.TypeOf(v))
+	return sequence{items: reflect.ValueOf(v)}, ok
+}
+
+// responderOf returns the responder of the return types writing the response themselves.
+func responderOf(v any) (responder, bool) {
+	if res, ok := v.(responder); ok {
+		return res, true
+	}
+	if seq, ok := sequenceOf(v); ok {
+		return seq, true
+	}
+	return nil, false
+}
+
+func (seq sequence) respond(w http.ResponseWriter, r *http.Request) error {
+	addVary(w.Header(), "Accept")
+
+	elem, _ := sequenceElem(seq.items.Type())
+	offers := sequenceOffers(r, elem)
+	accept := r.Header.Get("Accept")
+	acceptable := negotiate(accept, offers)
+	if len(acceptable) == 0 {
+		return NotAcceptableError{
+			Title:  "Not Acceptable",
+			Detail: "cannot produce any of the accepted media types " + accept + ", available media types are " + strings.Join(offers, ", "),
+			Err:    errors.New("no acceptable media type in " + accept),
+		}
+	}
+	ndjson := acceptable[0] == "application/x-ndjson"
+
+	w.Header().Set("Content-Type", acceptable[0])
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+
+	if !slices.Contains(sequenceMediaTypes, acceptable[0]) {
+		if encoder, ok := codecsFromRequest(r).encoder(acceptable[0], reflect.SliceOf(elem)).(sequenceEncoder); ok {
+			return encoder.encodeSequence(w, r, seq)
+		}
+	}
+
+	controller := http.NewResponseController(w)
+	lastFlush := time.Now()
+	encoder := json.NewEncoder(w)
+	first := true
+	var err error
+
+	if !ndjson {
+		_, err = w.Write([]byte("["))
+		if err != nil {
+			return err
+		}
+	}
+
+	seq.each(r, func(item any) bool {
+		if !ndjson && !first {
+			_, err = w.Write([]byte(","))
+			if err != nil {
+				return false
+			}
+		}
+		first = false
+
+		err = encoder.Encode(item)
+		if err != nil {
+			return false
+		}
+
+		if time.Since(lastFlush) >= sequenceFlushInterval {
+			lastFlush = time.Now()
+			_ = controller.Flush()
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if !ndjson {
+		_, err = w.Write([]byte("]\n"))
+	}
+	return err
+}
+
+// each calls fn on the items until it returns false, the sequence ends, or the client disconnects.
+func (seq sequence) each(r *http.Request, fn func(item any) bool) {
+	if seq.items.IsNil() {
+		return
+	}
+
+	ctx := r.Context()
+
+	if seq.items.Kind() == reflect.Chan {
+		cases := []reflect.SelectCase{
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+			{Dir: reflect.SelectRecv, Chan: seq.items},
+		}
+		for {
+			chosen, item, ok := reflect.Select(cases)
+			if chosen == 0 || !ok || !fn(item.Interface()) {
+				return
+			}
+		}
+	}
+
+	yieldType := seq.items.Type().In(0)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		keepGoing := ctx.Err() == nil && fn(args[0].Interface())
+		return []reflect.Value{reflect.ValueOf(keepGoing).Convert(yieldType.Out(0))}
+	})
+	seq.items.Call([]reflect.Value{yield})
+}
+
+// sequenceOffers returns the media types the sequence of items can be streamed as.
+// If the route declares the content types of its response, only the supported ones are offered.
+func sequenceOffers(r *http.Request, elem reflect.Type) []string {
+	supported := sequenceMediaTypes
+	if s := serverFromRequest(r); s != nil && s.contentNegotiation {
+		supported = appendMissing(slices.Clone(sequenceMediaTypes), codecsFromRequest(r).sequenceMediaTypes(elem, false)...)
+	}
+
+	if route := routeFromRequest(r); route != nil {
+		offers := []string{}
+		for _, contentType := range route.Response.ContentType {
+			contentType = strings.ToLower(contentType)
+			if slices.Contains(supported, contentType) && !slices.Contains(offers, contentType) {
+				offers = append(offers, contentType)
+			}
+		}
+		if len(offers) > 0 {
+			return offers
+		}
+	}
+
+	return supported
+}
+
+// sequenceMediaTypes returns the media types of the codecs streaming the sequences of items, see [sequenceEncoder].
+// If preferred is true, only the preferred media type of each codec is returned, as documented in the spec.
+func (codecs codecRegistry) sequenceMediaTypes(elem reflect.Type, preferred bool) []string {
+	mediaTypes := []string{}
+	for _, codec := range codecs {
+		if _, ok := codec.(sequenceEncoder); !ok || !canEncode(codec, reflect.SliceOf(elem)) || len(codec.MediaTypes()) == 0 {
+			continue
+		}
+		if preferred {
+			mediaTypes = appendMissing(mediaTypes, codec.MediaTypes()[0])
+		} else {
+			mediaTypes = appendMissing(mediaTypes, codec.MediaTypes()...)
+		}
+	}
+	return mediaTypes
+}
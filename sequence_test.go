@@ -0,0 +1,130 @@
+package fuego
+
+import (
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSequence(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	rows := []MyStruct{{B: "a", C: 1}, {B: "b", C: 2}, {B: "c", C: 3}}
+
+	Get(s.RouterGroup(), "/iter", func(c ContextNoBody) (iter.Seq[MyStruct], error) {
+		return slices.Values(rows), nil
+	})
+
+	Get(s.RouterGroup(), "/chan", func(c ContextNoBody) (<-chan MyStruct, error) {
+		ch := make(chan MyStruct)
+		go func() {
+			defer close(ch)
+			for _, row := range rows {
+				ch <- row
+			}
+		}()
+		return ch, nil
+	})
+
+	Get(s.RouterGroup(), "/empty", func(c ContextNoBody) (iter.Seq[MyStruct], error) {
+		return nil, nil
+	})
+
+	Get(s.RouterGroup(), "/ndjson-only", func(c ContextNoBody) (iter.Seq[MyStruct], error) {
+		return slices.Values(rows), nil
+	}).WithResponse(iter.Seq[MyStruct](nil), "application/x-ndjson")
+
+	Get(s.RouterGroup(), "/failing", func(c ContextNoBody) (iter.Seq[any], error) {
+		return slices.Values([]any{rows[0], func() {}}), nil
+	})
+
+	send := func(path, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	expectedArray := `[{"b":"a","c":1,"d":false},{"b":"b","c":2,"d":false},{"b":"c","c":3,"d":false}]`
+	expectedLines := "{\"b\":\"a\",\"c\":1,\"d\":false}\n{\"b\":\"b\",\"c\":2,\"d\":false}\n{\"b\":\"c\",\"c\":3,\"d\":false}\n"
+
+	t.Run("streams a JSON array by default", func(t *testing.T) {
+		w := send("/iter", "")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		require.JSONEq(t, expectedArray, w.Body.String())
+	})
+
+	t.Run("streams NDJSON", func(t *testing.T) {
+		w := send("/iter", "application/x-ndjson")
+
+		require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		require.Equal(t, expectedLines, w.Body.String())
+	})
+
+	t.Run("streams channels", func(t *testing.T) {
+		w := send("/chan", "application/json")
+		require.JSONEq(t, expectedArray, w.Body.String())
+
+		w = send("/chan", "application/x-ndjson")
+		require.Equal(t, expectedLines, w.Body.String())
+	})
+
+	t.Run("streams nil sequences as empty arrays", func(t *testing.T) {
+		w := send("/empty", "")
+		require.JSONEq(t, `[]`, w.Body.String())
+	})
+
+	t.Run("declared content types restrict the negotiation", func(t *testing.T) {
+		w := send("/ndjson-only", "")
+		require.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+		w = send("/ndjson-only", "application/json")
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("rejects other formats", func(t *testing.T) {
+		w := send("/iter", "application/xml")
+		require.Equal(t, http.StatusNotAcceptable, w.Code)
+	})
+
+	t.Run("aborts the connection on errors once the items are sent", func(t *testing.T) {
+		server := httptest.NewServer(s)
+		defer server.Close()
+
+		for _, accept := range []string{"application/json", "application/x-ndjson"} {
+			r, err := http.NewRequest(http.MethodGet, server.URL+"/failing", nil)
+			require.NoError(t, err)
+			r.Header.Set("Accept", accept)
+
+			res, err := http.DefaultClient.Do(r)
+			if err == nil {
+				_, err = io.ReadAll(res.Body)
+				res.Body.Close()
+			}
+			require.Error(t, err, "the truncated %s content is not mistaken for the whole one", accept)
+		}
+	})
+
+	t.Run("documents an array", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		content := s.OpenApiSpec.Paths.Find("/chan").Get.Responses.Status(http.StatusOK).Value.Content
+		require.Len(t, content, 2)
+		for _, mediaType := range []string{"application/json", "application/x-ndjson"} {
+			require.Contains(t, content, mediaType)
+			require.Equal(t, &openapi3.Types{"array"}, content[mediaType].Schema.Value.Type)
+			require.Equal(t, "#/components/schemas/MyStruct", content[mediaType].Schema.Value.Items.Ref)
+		}
+	})
+}
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"math"
 	"net/http"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -77,37 +79,106 @@ func transformOut[T any](ctx context.Context, ans T) (T, error) {
 
 type Sender func(http.ResponseWriter, *http.Request, any) error
 
+// responder is implemented by the controller return types that write the response themselves,
+// like [EventStream] or [File]. The serializer is not called for them.
+type responder interface {
+	respond(w http.ResponseWriter, r *http.Request) error
+}
+
+// responseDescriber is implemented by the controller return types documented with another schema
+// than their own, like [EventStream] or [File]. It must work on zero values.
+type responseDescriber interface {
+	describeResponse() (payload any, contentType string)
+}
+
+// respond lets the return value write the response.
+// Errors occurring before anything is written are serialized. Once the response has started,
+// they are logged and the connection is aborted with [http.ErrAbortHandler],
+// so the client does not mistake the truncated content for the whole one.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, res responder) {
+	err := res.respond(w, r)
+	if err == nil {
+		return
+	}
+
+	if written, ok := w.(interface{ Written() bool }); ok && !written.Written() {
+		s.serializeError(w, r, err)
+		return
+	}
+
+	slog.Error("Error while streaming the response, aborting the connection", "path", r.URL.Path, "error", err)
+	panic(http.ErrAbortHandler)
+}
+
+// HandleError answers the request with the error, like the Fuego controllers returning it do:
+// the error is transformed by the error handler of the server (see [WithContextErrorHandler]),
+// then serialized in the format negotiated with the Accept header.
+// It is meant for the standard middlewares and handlers, like the ones of [Security].
+// Outside of a Fuego server, [ErrorHandler] and [SendError] are used.
+func HandleError(w http.ResponseWriter, r *http.Request, err error) {
+	if s := serverFromRequest(r); s != nil {
+		s.serializeError(w, r, err)
+		return
+	}
+
+	SendError(w, r, ErrorHandler(err))
+}
+
+// handleError transforms the error with the context error handler of the server if set, or with its error handler.
+func (s *Server) handleError(ctx ContextNoBody, err error) error {
+	if s.ContextErrorHandler != nil {
+		return s.ContextErrorHandler(ctx, err)
+	}
+	return s.ErrorHandler(err)
+}
+
+// serializeError transforms the error with the error handler of the server, then serializes it.
+// The Retry-After header is set from [HTTPError.RetryAfter]. Nothing is written to the clients
+// that closed the request, only the 499 status code is recorded for the logs.
+func (s *Server) serializeError(w http.ResponseWriter, r *http.Request, err error) {
+	err = s.handleError(s.contextNoBody(w, r, nil), err)
+
+	var httpError HTTPError
+	if errors.As(err, &httpError) && httpError.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(httpError.RetryAfter.Seconds()))))
+	}
+
+	if errorStatus(err) == StatusClientClosedRequest {
+		w.WriteHeader(StatusClientClosedRequest)
+		return
+	}
+
+	s.SerializeError(w, r, err)
+}
+
 // Send sends a response.
-// The format is determined by the Accept header.
-// If Accept header `*/*` is found Send will Attempt to send
-// HTML, and then JSON.
-func Send(w http.ResponseWriter, r *http.Request, ans any) (err error) {
-	for _, header := range parseAcceptHeader(r.Header) {
-		switch inferAcceptHeader(header, ans) {
-		case "application/xml":
-			err = SendXML(w, nil, ans)
-		case "text/html":
-			err = SendHTML(w, r, ans)
-		case "text/plain":
-			err = SendText(w, nil, ans)
-		case "application/json":
-			err = SendJSON(w, nil, ans)
-		case "application/x-yaml", "text/yaml; charset=utf-8", "application/yaml": // https://www.rfc-editor.org/rfc/rfc9512.html
-			err = SendYAML(w, nil, ans)
-		default:
-			// if we don't support the header, try the next one
-			continue
-		}
+// The return types writing the response themselves, like [File] or [EventStream], are sent as is.
+// The iter.Seq[T] and channels of T are streamed as a JSON array, or as NDJSON (application/x-ndjson).
+// The format is negotiated with the Accept header (RFC 9110 section 12.5.1), by quality value and specificity,
+// among the content types declared by the route with [Route.WithResponse].
+// If the route does not declare any, the type inferred from the response is preferred
+// (HTML for renderers, text for strings, JSON otherwise), then the ones of the server codecs (see [WithCodec]).
+// It returns a [NotAcceptableError] if no format is acceptable.
+// It is the serializer of the servers created with [WithContentNegotiation].
+func Send(w http.ResponseWriter, r *http.Request, ans any) error {
+	if res, ok := responderOf(ans); ok {
+		return res.respond(w, r)
+	}
 
-		if err == nil {
-			return nil
-		}
+	addVary(w.Header(), "Accept")
+
+	codecs := codecsFromRequest(r)
+	offers := responseOffers(r, codecs, ans)
+	accept := r.Header.Get("Accept")
+	if acceptable := negotiate(accept, offers); len(acceptable) > 0 {
+		return codecs.lookup(acceptable[0]).Encode(w, r, ans)
 	}
 
-	if err != nil {
-		return err
+	return NotAcceptableError{
+		Title:  "Not Acceptable",
+		Detail: "cannot produce any of the accepted media types " + accept + ", available media types are " + strings.Join(offers, ", "),
+		Err:    errors.New("no acceptable media type in " + accept),
 	}
-	return errors.New("no supported Accept header was not provided from: " + r.Header.Get("Accept"))
 }
 
 // SendYAML sends a YAML response.
@@ -123,17 +194,17 @@ var SendYAML = func(w http.ResponseWriter, _ *http.Request, ans any) error {
 	return err
 }
 
-// SendYAMLError sends a YAML error response.
+// SendYAMLError sends a YAML problem details response (RFC 9457).
 // If the error implements ErrorWithStatus, the status code will be set.
-func SendYAMLError(w http.ResponseWriter, _ *http.Request, err error) {
-	status := http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		status = errorStatus.StatusCode()
-	}
+func SendYAMLError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(r, err)
 
-	w.WriteHeader(status)
-	_ = SendYAML(w, nil, err.Error())
+	w.Header().Set("Content-Type", "application/x-yaml")
+	w.WriteHeader(problem.Status)
+	encodingErr := yaml.NewEncoder(w).Encode(problem)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to YAML", "error", encodingErr)
+	}
 }
 
 // SendJSON sends a JSON response.
@@ -152,46 +223,31 @@ var SendJSON = func(w http.ResponseWriter, _ *http.Request, ans any) error {
 type ErrorSender = func(http.ResponseWriter, *http.Request, error)
 
 // SendError sends an error.
+// The format is negotiated with the Accept header like [Send], and defaults to JSON if no format is acceptable.
 // Declared as a variable to be able to override it for clients that need to customize serialization.
 var SendError = func(w http.ResponseWriter, r *http.Request, err error) {
-	for _, header := range parseAcceptHeader(r.Header) {
-		switch inferAcceptHeader(header, nil) {
-		case "application/xml":
-			SendXMLError(w, nil, err)
-		case "text/html":
-			SendHTMLError(w, nil, err)
-		case "text/plain":
-			SendTextError(w, r, err)
-		case "application/json":
-			SendJSONError(w, nil, err)
-		case "application/x-yaml", "text/yaml; charset=utf-8", "application/yaml": // https://www.rfc-editor.org/rfc/rfc9512.html
-			SendYAMLError(w, nil, err)
-		default:
-			continue
-		}
+	addVary(w.Header(), "Accept")
+
+	codecs := codecsFromRequest(r)
+	if acceptable := negotiate(r.Header.Get("Accept"), errorOffers(r, codecs)); len(acceptable) > 0 {
+		codecs.lookup(errorEncodingMediaType(acceptable[0])).EncodeError(w, r, err)
 		return
 	}
+
 	SendJSONError(w, r, err)
 }
 
-// SendJSONError sends a JSON error response.
+// SendJSONError sends a JSON problem details response (RFC 9457).
 // If the error implements ErrorWithStatus, the status code will be set.
-func SendJSONError(w http.ResponseWriter, _ *http.Request, err error) {
-	status := http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		status = errorStatus.StatusCode()
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-
-	var httpError HTTPError
-	if errors.As(err, &httpError) {
-		w.Header().Set("Content-Type", "application/problem+json")
+func SendJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(r, err)
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	encodingErr := json.NewEncoder(w).Encode(problem)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to JSON", "error", encodingErr)
 	}
-
-	w.WriteHeader(status)
-	_ = SendJSON(w, nil, err)
 }
 
 // SendXML sends a XML response.
@@ -201,20 +257,16 @@ var SendXML = func(w http.ResponseWriter, _ *http.Request, ans any) error {
 	return xml.NewEncoder(w).Encode(ans)
 }
 
-// SendXMLError sends a XML error response.
+// SendXMLError sends a XML problem details response (RFC 9457), as application/problem+xml.
 // If the error implements ErrorWithStatus, the status code will be set.
-func SendXMLError(w http.ResponseWriter, _ *http.Request, err error) {
-	status := http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		status = errorStatus.StatusCode()
-	}
-
-	w.WriteHeader(status)
-	err = SendXML(w, nil, err)
-	if err != nil {
-		slog.Error("Cannot serialize returned response to XML", "error", err)
-		_, _ = w.Write([]byte(`{"error":"Cannot serialize returned response to XML"}`))
+func SendXMLError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(r, err)
+
+	w.Header().Set("Content-Type", "application/problem+xml")
+	w.WriteHeader(problem.Status)
+	encodingErr := xml.NewEncoder(w).Encode(problem)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to XML", "error", encodingErr)
 	}
 }
 
@@ -249,17 +301,17 @@ var SendHTML = func(w http.ResponseWriter, r *http.Request, ans any) error {
 	return fmt.Errorf("cannot serialize HTML from type %T (not string, fuego.HTML and does not implement fuego.CtxRenderer or fuego.Renderer)", ans)
 }
 
-// SendHTMLError sends a HTML response.
+// SendHTMLError sends a HTML problem details page (RFC 9457).
 // If the error implements ErrorWithStatus, the status code will be set.
-func SendHTMLError(w http.ResponseWriter, _ *http.Request, err error) {
-	status := http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		status = errorStatus.StatusCode()
-	}
+func SendHTMLError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(r, err)
 
-	w.WriteHeader(status)
-	_ = SendHTML(w, nil, err.Error())
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	encodingErr := problem.writeHTML(w)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to HTML", "error", encodingErr)
+	}
 }
 
 // SendText sends a HTML response.
@@ -281,17 +333,17 @@ func SendText(w http.ResponseWriter, _ *http.Request, ans any) error {
 	return err
 }
 
-// SendTextError sends a Text response.
+// SendTextError sends a plain text problem details response (RFC 9457).
 // If the error implements ErrorWithStatus, the status code will be set.
-func SendTextError(w http.ResponseWriter, _ *http.Request, err error) {
-	status := http.StatusInternalServerError
-	var errorStatus ErrorWithStatus
-	if errors.As(err, &errorStatus) {
-		status = errorStatus.StatusCode()
-	}
+func SendTextError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemOf(r, err)
 
-	w.WriteHeader(status)
-	_ = SendText(w, nil, err.Error())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(problem.Status)
+	encodingErr := problem.writeText(w)
+	if encodingErr != nil {
+		slog.Error("Cannot serialize returned error to text", "error", encodingErr)
+	}
 }
 
 func InferAcceptHeaderFromType(ans any) string {
@@ -332,26 +384,3 @@ func InferAcceptHeaderFromType(ans any) string {
 
 	return "application/json"
 }
-
-func inferAcceptHeader(accept string, ans any) string {
-	if accept == "" || accept == "*/*" {
-		return InferAcceptHeaderFromType(ans)
-	}
-	return accept
-}
-
-func parseAcceptHeader(header http.Header) []string {
-	accept := header.Get("Accept")
-	if accept == "" {
-		return []string{""}
-	}
-	vals := strings.Split(accept, ",")
-	for i, v := range vals {
-		// ignore quality parameters if they exist
-		index := strings.Index(v, ";")
-		if index > 0 {
-			vals[i] = v[:index]
-		}
-	}
-	return vals
-}
@@ -1,11 +1,16 @@
 package fuego
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"log/slog"
+	"net"
 	"net/http"
 	"reflect"
+	"slices"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,13 +23,230 @@ const GinContextKey contextKeyType = gin.ContextKey
 // It is blocking.
 // It returns an error if the server could not start (it could not bind to the port for example).
 // It also generates the OpenAPI spec and outputs it to a file, the UI, and a handler (if enabled).
+// When the server is stopped with [Server.Shutdown], it returns once the in-flight requests are drained
+// and the [Server.OnShutdown] hooks are run, with the error of the shutdown if any.
 func (s *Server) Run(addr string) error {
-	// s.setup()
-	return s.Engine.Run(addr)
+	return s.RunWithContext(context.Background(), addr)
 }
 
+// RunWithContext starts the server like [Server.Run], and gracefully shuts it down when the context is done.
+// In-flight requests are drained and the [Server.OnShutdown] hooks are run, within the shutdown timeout
+// (see [WithShutdownTimeout]). For example, to stop on SIGTERM:
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+//	defer stop()
+//	err := s.RunWithContext(ctx, ":8080")
+func (s *Server) RunWithContext(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.serve(ctx, listener)
+}
+
+// serve runs the start hooks, then serves on the listener until the context is done or the server is shut down.
+func (s *Server) serve(ctx context.Context, listener net.Listener) error {
+	s.setup()
+	s.saveOpenAPISpec()
+
+	err := s.runStartHooks(ctx)
+	if err != nil {
+		_ = listener.Close()
+		return err
+	}
+
+	s.Server.Handler = s
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.Server.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			// Shut down from another goroutine: Serve returns at once, while the requests are still draining.
+			<-s.shutdownDone
+			return s.shutdownErr
+		}
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+		defer cancel()
+
+		return s.Shutdown(shutdownCtx)
+	}
+}
+
+// OnStart registers a hook run before the server starts listening, with [Server.Run] or [Server.RunWithContext].
+// Hooks run in registration order, with a deadline set by [WithStartTimeout].
+// If a hook returns an error, the following hooks are not run and the server does not start.
+func (s *Server) OnStart(hook func(ctx context.Context) error) {
+	s.startHooks = append(s.startHooks, hook)
+}
+
+// OnShutdown registers a hook run by [Server.Shutdown], once the in-flight requests are drained.
+// Useful to close database connections or flush buffers.
+// Hooks run in registration order, within the deadline of the shutdown context.
+func (s *Server) OnShutdown(hook func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, hook)
+}
+
+// Shutdown gracefully stops the server: it stops accepting connections, waits for in-flight requests
+// to complete, then runs the [Server.OnShutdown] hooks in order.
+// If the context expires first, the remaining hooks are skipped and the context error is returned.
+// Only the first call runs the hooks, later calls return the same result.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		errs := []error{}
+		err := s.Server.Shutdown(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shutting down http server: %w", err))
+		}
+
+		for i, hook := range s.shutdownHooks {
+			if ctx.Err() != nil {
+				errs = append(errs, fmt.Errorf("%d shutdown hook(s) skipped: %w", len(s.shutdownHooks)-i, ctx.Err()))
+				break
+			}
+
+			err := hook(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("shutdown hook %d: %w", i, err))
+			}
+		}
+
+		s.shutdownErr = errors.Join(errs...)
+		close(s.shutdownDone)
+	})
+
+	return s.shutdownErr
+}
+
+// runStartHooks runs the [Server.OnStart] hooks in order, within the start timeout.
+func (s *Server) runStartHooks(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.startTimeout)
+	defer cancel()
+
+	for i, hook := range s.startHooks {
+		err := hook(ctx)
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("start hook %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// ServeHTTP implements [http.Handler].
+// The server is set up on the first call, like with [Server.Run].
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	s.Engine.ServeHTTP(w, r)
+	s.setup()
+	s.handler.ServeHTTP(w, r)
+}
+
+// setup prepares the server before it handles its first request.
+// It outputs the OpenAPI spec: validation and routes serving the spec and the UI.
+// It also wraps the engine with the CORS handling and middleware, if any.
+// It only runs once, even if called several times.
+func (s *Server) setup() {
+	s.setupOnce.Do(func() {
+		s.OutputOpenAPISpec()
+
+		s.handler = s.handleOptions(s.Engine)
+		if s.corsConfig != nil {
+			s.handler = s.handleCors(s.handler)
+		}
+		if s.corsMiddleware != nil {
+			s.handler = s.corsMiddleware(s.handler)
+		}
+	})
+}
+
+// handleOptions answers OPTIONS requests on paths that have no OPTIONS route,
+// with the methods registered for the path in the Allow header.
+// For CORS preflight requests, Access-Control-Allow-Methods is set too if the CORS middleware did not set it,
+// like the middlewares only setting the allowed origin. [WithCors] answers the preflight requests before.
+func (s *Server) handleOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		methods := s.allowedMethods(r.URL.Path)
+		if len(methods) == 0 || slices.Contains(methods, http.MethodOptions) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allow := strings.Join(append(methods, http.MethodOptions), ", ")
+		w.Header().Set("Allow", allow)
+		if r.Header.Get("Access-Control-Request-Method") != "" && w.Header().Get("Access-Control-Allow-Methods") == "" {
+			w.Header().Set("Access-Control-Allow-Methods", allow)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// allowedMethods returns the sorted methods of the registered routes matching the given path.
+func (s *Server) allowedMethods(path string) []string {
+	methods := []string{}
+	for _, route := range s.routes {
+		if !matchGinPath(route.Path, path) {
+			continue
+		}
+
+		if route.All || route.Method == "" {
+			return slices.Clone(anyMethods)
+		}
+
+		if !slices.Contains(methods, route.Method) {
+			methods = append(methods, route.Method)
+		}
+	}
+
+	slices.Sort(methods)
+	return methods
+}
+
+// anyMethods are the methods registered by gin for routes declared with [All].
+var anyMethods = []string{
+	http.MethodConnect, http.MethodDelete, http.MethodGet, http.MethodHead,
+	http.MethodOptions, http.MethodPatch, http.MethodPost, http.MethodPut, http.MethodTrace,
+}
+
+// matchGinPath reports whether the request path matches the gin route pattern.
+// For example, /users/:id matches /users/123 and /static/*filepath matches /static/css/main.css.
+func matchGinPath(pattern, path string) bool {
+	patternSegments := strings.Split(pattern, "/")
+	pathSegments := strings.Split(path, "/")
+
+	for i, segment := range patternSegments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+
+		if i >= len(pathSegments) {
+			return false
+		}
+
+		if strings.HasPrefix(segment, ":") {
+			if pathSegments[i] == "" {
+				return false
+			}
+			continue
+		}
+
+		if segment != pathSegments[i] {
+			return false
+		}
+	}
+
+	return len(patternSegments) == len(pathSegments)
 }
 
 // initializes any Context type with the base ContextNoBody context.
@@ -43,17 +265,44 @@ func initContext[Contextable ctx[Body], Body any](baseContext ContextNoBody) Con
 			ContextNoBody: baseContext,
 		}).(Contextable)
 	default:
+		// Generic contexts built on top of ContextNoBody, like ContextWithParams[Params]
+		contextType := reflect.TypeOf(c)
+		if contextType != nil && contextType.Kind() == reflect.Ptr {
+			if initializer, ok := reflect.New(contextType.Elem()).Interface().(contextInitializer); ok {
+				initializer.initContext(baseContext)
+				return initializer.(Contextable)
+			}
+		}
 		panic("unknown type")
 	}
 }
 
+// contextNoBody returns the base context of the request, with the options of the server.
+// The gin context is nil outside of the gin handlers.
+func (s *Server) contextNoBody(w http.ResponseWriter, r *http.Request, c *gin.Context) ContextNoBody {
+	return ContextNoBody{
+		Req: r,
+		Res: w,
+		readOptions: readOptions{
+			DisallowUnknownFields: s.DisallowUnknownFields,
+			MaxBodySize:           s.maxBodySize,
+			MaxFileSize:           s.maxFileSize,
+			Validator:             s.validator,
+			Locale:                mainLocale(r),
+		},
+		codecs: s.codecs,
+		fs:     s.fs,
+		ginCtx: c,
+	}
+}
+
 // FuegoHandler converts a Fuego controller into a http.HandlerFunc.
 func FuegoHandler[ReturnType, Body any, Contextable ctx[Body]](s *Server, controller func(c Contextable) (ReturnType, error)) gin.HandlerFunc {
 	// Just a check, not used at request time
 	baseContext := *new(Contextable)
 	if reflect.TypeOf(baseContext) == nil {
 		slog.Info(fmt.Sprintf("context is nil: %v %T", baseContext, baseContext))
-		panic("ctx must be provided as concrete type (not interface). ContextNoBody, ContextWithBody[any], ContextFull[any, any], ContextWithQueryParams[any] are supported")
+		panic("ctx must be provided as concrete type (not interface). ContextNoBody, ContextWithBody[any], ContextWithParams[any], ContextFull[any, any] are supported")
 	}
 
 	return func(c *gin.Context) {
@@ -63,43 +312,70 @@ func FuegoHandler[ReturnType, Body any, Contextable ctx[Body]](s *Server, contro
 			templates = template.Must(s.template.Clone())
 		}
 
-		ctx := initContext[Contextable](ContextNoBody{
-			Req: c.Request,
-			Res: c.Writer,
-			readOptions: readOptions{
-				DisallowUnknownFields: s.DisallowUnknownFields,
-				MaxBodySize:           s.maxBodySize,
-			},
-			fs:        s.fs,
-			templates: templates,
-			ginCtx:    c,
-		})
+		baseContext := s.contextNoBody(c.Writer, c.Request, c)
+		baseContext.templates = templates
+		ctx := initContext[Contextable](baseContext)
 
 		// CONTROLLER
+		status := c.Writer.Status()
 		ans, err := controller(ctx)
 		if err != nil {
-			err = s.ErrorHandler(err)
-			s.SerializeError(c.Writer, c.Request, err)
+			s.serializeError(c.Writer, c.Request, err)
 			return
 		}
 
 		if reflect.TypeOf(ans) == nil {
+			writeDefaultStatus(c, status)
+			return
+		}
+
+		if res, ok := responderOf(ans); ok {
+			writeDefaultStatus(c, status)
+			s.respond(c.Writer, c.Request, res)
 			return
 		}
 
 		// TRANSFORM OUT
 		ans, err = transformOut(c.Request.Context(), ans)
 		if err != nil {
-			err = s.ErrorHandler(err)
-			s.SerializeError(c.Writer, c.Request, err)
+			s.serializeError(c.Writer, c.Request, err)
+			return
+		}
+
+		var data any = ans
+		if envelope, ok := any(ans).(responseEnvelope); ok {
+			data, err = envelope.writeHeaders(c.Request.Context(), c.Writer)
+			if err != nil {
+				s.serializeError(c.Writer, c.Request, err)
+				return
+			}
+		}
+
+		writeDefaultStatus(c, status)
+		if data == nil {
 			return
 		}
 
 		// SERIALIZATION
-		err = s.Serialize(c.Writer, c.Request, ans)
+		serialize := s.Serialize
+		switch data.(type) {
+		case CtxRenderer, Renderer:
+			// The templates and components are rendered as HTML, whatever the serializer of the server.
+			serialize = SendHTML
+		}
+		err = serialize(c.Writer, c.Request, data)
 		if err != nil {
-			err = s.ErrorHandler(err)
-			s.SerializeError(c.Writer, c.Request, err)
+			s.serializeError(c.Writer, c.Request, err)
 		}
 	}
 }
+
+// writeDefaultStatus sets the status code declared with [Route.Status] on a successful response,
+// unless the controller already set another one than the status before it ran, or wrote the response.
+func writeDefaultStatus(c *gin.Context, status int) {
+	route := routeFromRequest(c.Request)
+	if route == nil || route.DefaultStatusCode == 0 || c.Writer.Written() || c.Writer.Status() != status {
+		return
+	}
+	c.Writer.WriteHeader(route.DefaultStatusCode)
+}
@@ -0,0 +1,277 @@
+package fuego
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorsMiddleware(t *testing.T) {
+	corsMiddleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	s := NewServer(
+		WithoutLogger(),
+		WithCorsMiddleware(corsMiddleware),
+	)
+
+	Get(s.RouterGroup(), "/users", func(ContextNoBody) (string, error) {
+		return "users", nil
+	})
+	Post(s.RouterGroup(), "/users", func(ContextNoBody) (string, error) {
+		return "created", nil
+	})
+	Delete(s.RouterGroup(), "/users/:id", func(ContextNoBody) (string, error) {
+		return "deleted", nil
+	})
+	optionsController := func(ContextNoBody) (string, error) { return "custom options", nil }
+	Register(s.RouterGroup(), Route{Method: http.MethodOptions, Path: "/custom"}, FuegoHandler(s, optionsController))
+
+	t.Run("CORS middleware wraps registered routes", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.Header.Set("Origin", "http://example.com")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight on a path without OPTIONS route", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodOptions, "/users", nil)
+		r.Header.Set("Origin", "http://example.com")
+		r.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Allow"))
+		require.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("preflight on a path with parameters", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodOptions, "/users/123", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "DELETE, OPTIONS", w.Header().Get("Allow"))
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("declared OPTIONS route is used", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodOptions, "/custom", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "custom options")
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodOptions, "/unknown", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestMatchGinPath(t *testing.T) {
+	require.True(t, matchGinPath("/", "/"))
+	require.True(t, matchGinPath("/users/:id", "/users/123"))
+	require.True(t, matchGinPath("/static/*filepath", "/static/css/main.css"))
+	require.False(t, matchGinPath("/users/:id", "/users/"))
+	require.False(t, matchGinPath("/users/:id", "/users/123/posts"))
+	require.False(t, matchGinPath("/users", "/posts"))
+}
+
+func TestServerLifecycle(t *testing.T) {
+	newServer := func(events *[]string) *Server {
+		s := NewServer(
+			WithoutLogger(),
+			WithOpenAPIConfig(OpenAPIConfig{DisableLocalSave: true, DisableSwagger: true}),
+			WithShutdownTimeout(5*time.Second),
+		)
+		s.OnStart(func(ctx context.Context) error {
+			*events = append(*events, "start 1")
+			return nil
+		})
+		s.OnStart(func(ctx context.Context) error {
+			*events = append(*events, "start 2")
+			return nil
+		})
+		s.OnShutdown(func(ctx context.Context) error {
+			*events = append(*events, "shutdown 1")
+			return nil
+		})
+		s.OnShutdown(func(ctx context.Context) error {
+			*events = append(*events, "shutdown 2")
+			return nil
+		})
+		return s
+	}
+
+	t.Run("drains in-flight requests when the context is done", func(t *testing.T) {
+		events := []string{}
+		s := newServer(&events)
+
+		requestStarted := make(chan struct{})
+		Get(s.RouterGroup(), "/slow", func(c ContextNoBody) (string, error) {
+			close(requestStarted)
+			time.Sleep(100 * time.Millisecond)
+			return "done", nil
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- s.serve(ctx, listener)
+		}()
+
+		responseBody := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+			if err != nil {
+				responseBody <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			responseBody <- string(body)
+		}()
+
+		<-requestStarted
+		cancel()
+
+		require.NoError(t, <-runErr)
+		require.Equal(t, `"done"`+"\n", <-responseBody)
+		require.Equal(t, []string{"start 1", "start 2", "shutdown 1", "shutdown 2"}, events)
+	})
+
+	t.Run("Shutdown stops Run and runs the hooks once", func(t *testing.T) {
+		events := []string{}
+		s := newServer(&events)
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- s.serve(context.Background(), listener)
+		}()
+
+		require.Eventually(t, func() bool {
+			resp, err := http.Get("http://" + listener.Addr().String() + "/unknown")
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return true
+		}, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, s.Shutdown(context.Background()))
+		require.NoError(t, s.Shutdown(context.Background()))
+		require.NoError(t, <-runErr)
+		require.Equal(t, []string{"start 1", "start 2", "shutdown 1", "shutdown 2"}, events)
+	})
+
+	t.Run("Run returns once a concurrent Shutdown is done", func(t *testing.T) {
+		events := []string{}
+		s := newServer(&events)
+		s.OnShutdown(func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return errors.New("cannot flush")
+		})
+
+		requestStarted := make(chan struct{})
+		Get(s.RouterGroup(), "/slow", func(c ContextNoBody) (string, error) {
+			close(requestStarted)
+			time.Sleep(100 * time.Millisecond)
+			return "done", nil
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		runErr := make(chan error, 1)
+		go func() {
+			runErr <- s.serve(context.Background(), listener)
+		}()
+
+		responseBody := make(chan string, 1)
+		go func() {
+			resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+			if err != nil {
+				responseBody <- err.Error()
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			responseBody <- string(body)
+		}()
+
+		<-requestStarted
+		go func() {
+			_ = s.Shutdown(context.Background())
+		}()
+
+		require.ErrorContains(t, <-runErr, "cannot flush")
+		require.Equal(t, []string{"start 1", "start 2", "shutdown 1", "shutdown 2"}, events, "the hooks have run when Run returns")
+		require.Equal(t, `"done"`+"\n", <-responseBody)
+	})
+
+	t.Run("failing start hook prevents the server from starting", func(t *testing.T) {
+		events := []string{}
+		s := newServer(&events)
+		s.OnStart(func(ctx context.Context) error {
+			return errors.New("database unreachable")
+		})
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		err = s.serve(context.Background(), listener)
+		require.ErrorContains(t, err, "database unreachable")
+		require.Equal(t, []string{"start 1", "start 2"}, events)
+	})
+
+	t.Run("shutdown hooks errors are returned", func(t *testing.T) {
+		s := NewServer(WithoutLogger())
+		s.OnShutdown(func(ctx context.Context) error {
+			return errors.New("cannot flush")
+		})
+
+		err := s.Shutdown(context.Background())
+		require.ErrorContains(t, err, "cannot flush")
+	})
+}
+
+func TestServerTimeouts(t *testing.T) {
+	s := NewServer(
+		WithReadTimeout(time.Second),
+		WithReadHeaderTimeout(2*time.Second),
+		WithWriteTimeout(3*time.Second),
+		WithIdleTimeout(4*time.Second),
+	)
+
+	require.Equal(t, time.Second, s.Server.ReadTimeout)
+	require.Equal(t, 2*time.Second, s.Server.ReadHeaderTimeout)
+	require.Equal(t, 3*time.Second, s.Server.WriteTimeout)
+	require.Equal(t, 4*time.Second, s.Server.IdleTimeout)
+}
@@ -0,0 +1,118 @@
+package fuego
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event is a Server-Sent Event, streamed by an [EventStream].
+type Event[T any] struct {
+	ID    string        // Event ID. The client sends the last received one back in the Last-Event-ID header when reconnecting.
+	Event string        // Event type. If empty, the client dispatches it as a "message" event.
+	Data  T             // Event payload, encoded as JSON.
+	Retry time.Duration // Reconnection delay for the client. Ignored if zero.
+}
+
+// EventStream is a controller return type streaming Server-Sent Events to the client.
+// Each event is written and flushed as soon as it is yielded.
+// The stream stops when the sequence ends or when the client disconnects:
+// yield returns false and the [context.Context] of the request is done.
+// For example:
+//
+//	fuego.Get(s.RouterGroup(), "/events", func(c fuego.ContextNoBody) (fuego.EventStream[Message], error) {
+//		lastID := c.LastEventID()
+//		return func(yield func(fuego.Event[Message]) bool) {
+//			for msg := range messagesSince(c.Context(), lastID) {
+//				if !yield(fuego.Event[Message]{ID: msg.ID, Data: msg}) {
+//					return
+//				}
+//			}
+//		}, nil
+//	})
+//
+// The route is documented with the text/event-stream content type and the schema of the event payload.
+type EventStream[T any] iter.Seq[Event[T]]
+
+// Events converts a sequence of payloads into an [EventStream] of unnamed events without ID.
+func Events[T any](seq iter.Seq[T]) EventStream[T] {
+	return func(yield func(Event[T]) bool) {
+		for data := range seq {
+			if !yield(Event[T]{Data: data}) {
+				return
+			}
+		}
+	}
+}
+
+func (stream EventStream[T]) describeResponse() (any, string) {
+	return *new(T), "text/event-stream"
+}
+
+func (stream EventStream[T]) respond(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	w.WriteHeader(http.StatusOK)
+
+	controller := http.NewResponseController(w)
+	err := controller.Flush()
+	if err != nil {
+		return fmt.Errorf("cannot stream events: %w", err)
+	}
+
+	if stream == nil {
+		return nil
+	}
+
+	for event := range stream {
+		if r.Context().Err() != nil {
+			return nil
+		}
+
+		err := writeEvent(w, event)
+		if err != nil {
+			return err
+		}
+
+		err = controller.Flush()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeEvent writes an event in the text/event-stream format.
+func writeEvent[T any](w http.ResponseWriter, event Event[T]) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("cannot encode event data: %w", err)
+	}
+
+	var sb strings.Builder
+	if event.ID != "" {
+		sb.WriteString("id: " + sanitizeEventField(event.ID) + "\n")
+	}
+	if event.Event != "" {
+		sb.WriteString("event: " + sanitizeEventField(event.Event) + "\n")
+	}
+	if event.Retry > 0 {
+		sb.WriteString(fmt.Sprintf("retry: %d\n", event.Retry.Milliseconds()))
+	}
+	sb.WriteString("data: ")
+	sb.Write(data)
+	sb.WriteString("\n\n")
+
+	_, err = w.Write([]byte(sb.String()))
+	return err
+}
+
+// sanitizeEventField removes the line breaks that would end the field early.
+func sanitizeEventField(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
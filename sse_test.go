@@ -0,0 +1,105 @@
+package fuego
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sseMessage struct {
+	Text string `json:"text"`
+}
+
+func TestEventStream(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Get(s.RouterGroup(), "/events", func(c ContextNoBody) (EventStream[sseMessage], error) {
+		lastID := c.LastEventID()
+		return func(yield func(Event[sseMessage]) bool) {
+			if lastID == "" {
+				if !yield(Event[sseMessage]{ID: "1", Event: "greeting", Data: sseMessage{Text: "hello"}, Retry: 3 * time.Second}) {
+					return
+				}
+			}
+			yield(Event[sseMessage]{ID: "2", Data: sseMessage{Text: "after " + lastID}})
+		}, nil
+	})
+
+	Get(s.RouterGroup(), "/numbers", func(c ContextNoBody) (EventStream[int], error) {
+		return Events(slices.Values([]int{1, 2, 3})), nil
+	})
+
+	infiniteStreamStopped := make(chan struct{})
+	Get(s.RouterGroup(), "/infinite", func(c ContextNoBody) (EventStream[int], error) {
+		return func(yield func(Event[int]) bool) {
+			defer close(infiniteStreamStopped)
+			for i := 0; ; i++ {
+				if !yield(Event[int]{Data: i}) {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}, nil
+	})
+
+	t.Run("streams events", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/events", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		require.Equal(t, "no-cache", w.Header().Get("Cache-Control"))
+		require.True(t, w.Flushed)
+		require.Equal(t, "id: 1\nevent: greeting\nretry: 3000\ndata: {\"text\":\"hello\"}\n\n"+
+			"id: 2\ndata: {\"text\":\"after \"}\n\n", w.Body.String())
+	})
+
+	t.Run("resumes from Last-Event-ID", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/events", nil)
+		r.Header.Set("Last-Event-ID", "1")
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, "id: 2\ndata: {\"text\":\"after 1\"}\n\n", w.Body.String())
+	})
+
+	t.Run("from a sequence", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/numbers", nil)
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, "data: 1\n\ndata: 2\n\ndata: 3\n\n", w.Body.String())
+	})
+
+	t.Run("stops when the client disconnects", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/infinite", nil).WithContext(ctx)
+		s.ServeHTTP(w, r)
+
+		select {
+		case <-infiniteStreamStopped:
+		case <-time.After(time.Second):
+			t.Fatal("stream did not stop")
+		}
+	})
+
+	t.Run("documents the event payload", func(t *testing.T) {
+		s.OutputOpenAPISpec()
+
+		response := s.OpenApiSpec.Paths.Find("/events").Get.Responses.Status(200)
+		require.NotNil(t, response)
+		mediaType := response.Value.Content.Get("text/event-stream")
+		require.NotNil(t, mediaType)
+		require.Equal(t, "#/components/schemas/SseMessage", mediaType.Schema.Ref)
+		require.Nil(t, response.Value.Content.Get("application/json"))
+	})
+}
@@ -1,6 +1,7 @@
 package main_test
 
 import (
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 func TestCors(t *testing.T) {
 	s := fuego.NewServer(
 		fuego.WithoutLogger(),
+		fuego.WithSerializer(fuego.Send),
 		fuego.WithCorsMiddleware(cors.New(cors.Options{
 			AllowedOrigins: []string{"*"},
 			AllowedMethods: []string{"GET"},
@@ -23,18 +25,73 @@ func TestCors(t *testing.T) {
 		return "Hello, World!", nil
 	})
 
-	t.Run("CORS request INCOMPLETE TEST", func(t *testing.T) {
+	t.Run("CORS request", func(t *testing.T) {
 		r := httptest.NewRequest("GET", "http://example.com/", nil)
 		w := httptest.NewRecorder()
 
 		r.Header.Set("Origin", "http://example.com/")
-		r.Header.Set("Access-Control-Request-Method", "GET")
 
 		s.ServeHTTP(w, r)
 
-		t.Log(w.Header())
-		body := w.Body.String()
-		require.Equal(t, "Hello, World!", body)
+		require.Equal(t, "Hello, World!", w.Body.String())
 		require.Equal(t, 200, w.Code)
+		require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("preflight request on a route without OPTIONS handler", func(t *testing.T) {
+		r := httptest.NewRequest("OPTIONS", "http://example.com/", nil)
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Origin", "http://example.com/")
+		r.Header.Set("Access-Control-Request-Method", "GET")
+
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "GET", w.Header().Get("Access-Control-Allow-Methods"))
+	})
+
+	t.Run("preflight request for a method not allowed by CORS", func(t *testing.T) {
+		r := httptest.NewRequest("OPTIONS", "http://example.com/", nil)
+		w := httptest.NewRecorder()
+
+		r.Header.Set("Origin", "http://example.com/")
+		r.Header.Set("Access-Control-Request-Method", "DELETE")
+
+		s.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNoContent, w.Code)
+		require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
+
+func TestCorsPassthrough(t *testing.T) {
+	s := fuego.NewServer(
+		fuego.WithoutLogger(),
+		fuego.WithCorsMiddleware(cors.New(cors.Options{
+			AllowedOrigins:     []string{"*"},
+			AllowedMethods:     []string{"GET", "POST", "DELETE"},
+			OptionsPassthrough: true,
+		}).Handler),
+	)
+
+	fuego.Get(s.RouterGroup(), "/items/:id", func(c fuego.ContextNoBody) (string, error) {
+		return "item", nil
+	})
+	fuego.Delete(s.RouterGroup(), "/items/:id", func(c fuego.ContextNoBody) (string, error) {
+		return "deleted", nil
 	})
+
+	r := httptest.NewRequest("OPTIONS", "http://example.com/items/42", nil)
+	w := httptest.NewRecorder()
+
+	r.Header.Set("Origin", "http://example.com/")
+	r.Header.Set("Access-Control-Request-Method", "DELETE")
+
+	s.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "DELETE, GET, OPTIONS", w.Header().Get("Allow"))
 }
@@ -7,7 +7,6 @@ import (
 
 	"github.com/fourcorelabs/fuego"
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/gin-gonic/gin"
 )
 
 func fuegoRouter(ctx fuego.ContextNoBody) (string, error) {
@@ -51,6 +50,11 @@ func openAPIRouter() *fuego.Server {
 		fuego.WithSerializer(fuego.Send),
 		fuego.WithGlobalResponseTypes(http.StatusBadRequest, fuego.HTTPError{}, "Bad Request _(validation or deserialization error)_"),
 		fuego.WithGlobalResponseTypes(http.StatusInternalServerError, fuego.HTTPError{}, "Internal Server Error"),
+		fuego.WithOpenAPIConfig(fuego.OpenAPIConfig{
+			JsonUrl:          "/openapi.json",
+			SwaggerUrl:       "/api/docs",
+			DisableLocalSave: true,
+		}),
 	)
 
 	s.OpenApiSpec = spec
@@ -60,25 +64,14 @@ func openAPIRouter() *fuego.Server {
 func main() {
 	s := openAPIRouter()
 
-	noAuthRouteNoDoc := s.RouterGroup().Group("", fuego.WithoutTag()).Hide()
-	fuego.GetGin(noAuthRouteNoDoc, "/openapi.json", func(ctx *gin.Context) {
-		fuego.SendJSON(ctx.Writer, ctx.Request, s.OpenApiSpec)
-	})
-
-	fuego.GetGin(s.RouterGroup(), "/api/docs", func(ctx *gin.Context) {
-		fuego.DefaultOpenAPIHandler("/openapi.json").ServeHTTP(ctx.Writer, ctx.Request)
-	}).Summary("myname").Build()
-
 	fuego.Get(s.RouterGroup(), "/:id", fuegoRouter).
 		Query("filter", "my desc", fuego.WithAllowReserved()).
 		Summary("hello world").
 		Description("my world is here").
-		RequestDescription("hello world my request").
-		// WithRequest(request{}, "my req desc").
-		// WithResponse(request{}, "my req desc").
-		Build()
+		RequestDescription("hello world my request")
 
-	if err := s.OpenApiSpec.Validate(context.Background()); err != nil {
+	spec := s.OutputOpenAPISpec()
+	if err := spec.Validate(context.Background()); err != nil {
 		log.Panic(err)
 	}
 
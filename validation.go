@@ -3,24 +3,51 @@ package fuego
 import (
 	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/ja"
+	"github.com/go-playground/locales/nl"
+	"github.com/go-playground/locales/pt"
+	"github.com/go-playground/locales/pt_BR"
+	"github.com/go-playground/locales/ru"
+	"github.com/go-playground/locales/tr"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	nl_translations "github.com/go-playground/validator/v10/translations/nl"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+	pt_BR_translations "github.com/go-playground/validator/v10/translations/pt_BR"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+	tr_translations "github.com/go-playground/validator/v10/translations/tr"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
 )
 
-// explainError translates a validator error into a human readable string.
-func explainError(err validator.FieldError) string {
+// explainError translates a validator error into a human readable string, naming the field name.
+func explainError(err validator.FieldError, name string) string {
 	switch err.Tag() {
 	case "required":
-		return fmt.Sprintf("%s is required", err.Field())
+		return fmt.Sprintf("%s is required", name)
 	case "email":
-		return fmt.Sprintf("%s should be a valid email", err.Field())
+		return fmt.Sprintf("%s should be a valid email", name)
 	case "uuid":
-		return fmt.Sprintf("%s should be a valid UUID", err.Field())
+		return fmt.Sprintf("%s should be a valid UUID", name)
 	case "e164":
-		return fmt.Sprintf("%s should be a valid international phone number (e.g. +33 6 06 06 06 06)", err.Field())
+		return fmt.Sprintf("%s should be a valid international phone number (e.g. +33 6 06 06 06 06)", name)
 	default:
-		resp := fmt.Sprintf("%s should be %s", err.Field(), err.Tag())
+		resp := fmt.Sprintf("%s should be %s", name, err.Tag())
 		if err.Param() != "" {
 			resp += "=" + err.Param()
 		}
@@ -28,15 +55,189 @@ func explainError(err validator.FieldError) string {
 	}
 }
 
-var v = validator.New()
+// ValidationRule is a custom validation tag, registered with [WithValidationRule].
+// For example, with a rule for the "sku" tag, the fields tagged `validate:"sku"` are validated by its Func.
+type ValidationRule struct {
+	Tag  string         // Name of the tag, used in the `validate` struct tags.
+	Func validator.Func // Validation function of the tag.
 
-func validate(a any) error {
-	_, ok := a.(map[string]any)
-	if ok {
+	// Validation messages by locale (ex: "en", "fr", "pt_BR"), where {0} is the field name and {1} the tag parameter.
+	// Defaults to "{0} should be <tag>=<param>".
+	Messages map[string]string
+
+	// Documents the rule in the OpenAPI schema of the fields using the tag, with the parameter of the tag if any.
+	// For example, func(schema *openapi3.Schema, _ string) { schema.Pattern = `^[A-Z]{3}-\d{4}$` }.
+	Schema func(schema *openapi3.Schema, param string)
+}
+
+// validationTranslations are the locales of the translated validation messages, with the validator translations.
+// English comes first: its messages are used for the other locales, and when the request has no Accept-Language.
+var validationTranslations = []struct {
+	locale   locales.Translator
+	register func(*validator.Validate, ut.Translator) error
+}{
+	{en.New(), registerEnglishTranslations},
+	{es.New(), es_translations.RegisterDefaultTranslations},
+	{fr.New(), fr_translations.RegisterDefaultTranslations},
+	{it.New(), it_translations.RegisterDefaultTranslations},
+	{ja.New(), ja_translations.RegisterDefaultTranslations},
+	{nl.New(), nl_translations.RegisterDefaultTranslations},
+	{pt.New(), pt_translations.RegisterDefaultTranslations},
+	{pt_BR.New(), pt_BR_translations.RegisterDefaultTranslations},
+	{ru.New(), ru_translations.RegisterDefaultTranslations},
+	{tr.New(), tr_translations.RegisterDefaultTranslations},
+	{zh.New(), zh_translations.RegisterDefaultTranslations},
+}
+
+// explainedTags are the tags whose English messages are the ones of [explainError], instead of the validator ones.
+var explainedTags = []string{"required", "email", "uuid", "e164"}
+
+// registerEnglishTranslations registers the English validator translations, keeping the messages of [explainError] for its tags.
+func registerEnglishTranslations(validate *validator.Validate, trans ut.Translator) error {
+	err := en_translations.RegisterDefaultTranslations(validate, trans)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range explainedTags {
+		err = validate.RegisterTranslation(tag, trans,
+			func(ut.Translator) error { return nil },
+			func(_ ut.Translator, fe validator.FieldError) string { return explainError(fe, fe.Field()) },
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structValidator validates the request bodies and parameters of a server, with its custom rules,
+// and translates the validation messages in the locale of the requests.
+// The translations are registered when it is created, as the validator is not safe for concurrent registrations.
+type structValidator struct {
+	validate   *validator.Validate
+	rules      map[string]ValidationRule
+	translator *ut.UniversalTranslator
+}
+
+// defaultValidator is the validator used outside of a server, for example by [ReadJSON].
+var defaultValidator = newStructValidator(validator.New())
+
+func newStructValidator(validate *validator.Validate) *structValidator {
+	sv := &structValidator{
+		validate: validate,
+		rules:    map[string]ValidationRule{},
+	}
+	sv.registerTranslations()
+	return sv
+}
+
+// withValidate returns a new validator using validate, with the same custom rules.
+func (sv *structValidator) withValidate(validate *validator.Validate) *structValidator {
+	newValidator := newStructValidator(validate)
+	for _, rule := range sv.rules {
+		newValidator.withRule(rule)
+	}
+	return newValidator
+}
+
+// withRule registers the custom rule. It panics if the tag cannot be registered, like the other invalid options.
+func (sv *structValidator) withRule(rule ValidationRule) {
+	err := sv.validate.RegisterValidation(rule.Tag, rule.Func)
+	if err != nil {
+		panic(fmt.Sprintf("cannot register validation rule %q: %s", rule.Tag, err))
+	}
+	sv.rules[rule.Tag] = rule
+}
+
+// localeNames returns the names of the locale (ex: fr-CA, fr;q=0.9) in the locales package (ex: fr_CA) and of its language.
+func localeNames(locale string) (string, string) {
+	locale, _, _ = strings.Cut(strings.TrimSpace(locale), ";")
+	locale = strings.ReplaceAll(locale, "-", "_")
+	language, _, _ := strings.Cut(locale, "_")
+	return locale, language
+}
+
+// translatorFor returns the translator of the locale, or the English one if the locale is not translated.
+func (sv *structValidator) translatorFor(locale string) ut.Translator {
+	locale, language := localeNames(locale)
+	trans, _ := sv.translator.FindTranslator(locale, language)
+	return trans
+}
+
+func (sv *structValidator) registerTranslations() {
+	translators := make([]locales.Translator, 0, len(validationTranslations))
+	for _, translation := range validationTranslations {
+		translators = append(translators, translation.locale)
+	}
+	sv.translator = ut.New(translators[0], translators...)
+
+	for _, translation := range validationTranslations {
+		trans, _ := sv.translator.GetTranslator(translation.locale.Locale())
+		err := translation.register(sv.validate, trans)
+		if err != nil {
+			panic(fmt.Sprintf("cannot register the %s validation messages: %s", translation.locale.Locale(), err))
+		}
+	}
+}
+
+// message returns the validation message of the error, in the locale if it is translated.
+// The field is named name, its name in the decoded format, instead of its Go name.
+func (sv *structValidator) message(err validator.FieldError, locale, name string) string {
+	if rule, ok := sv.rules[err.Tag()]; ok {
+		locale, language := localeNames(locale)
+		for _, messageLocale := range []string{locale, language, "en"} {
+			if message, ok := rule.Messages[messageLocale]; ok {
+				return strings.NewReplacer("{0}", name, "{1}", err.Param()).Replace(message)
+			}
+		}
+		return explainError(err, name)
+	}
+
+	// Translate returns the untranslated error for the tags without translation.
+	if message := err.Translate(sv.translatorFor(locale)); message != err.Error() {
+		return renameField(message, err.Field(), name)
+	}
+
+	return explainError(err, name)
+}
+
+// renameField replaces the first occurrence of the Go name of the field in the translated message.
+// The validator translations name the fields by their Go names, as returned by [validator.FieldError.Field].
+func renameField(message, field, name string) string {
+	for start := 0; start < len(message); {
+		i := strings.Index(message[start:], field)
+		if i < 0 {
+			break
+		}
+		i += start
+		end := i + len(field)
+		if !isWordByte(message, i-1) && !isWordByte(message, end) {
+			return message[:i] + name + message[end:]
+		}
+		start = i + 1
+	}
+	return message
+}
+
+// isWordByte reports whether the byte at index i of s is an ASCII letter, digit or underscore, like \w in regular expressions.
+func isWordByte(s string, i int) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	c := s[i]
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// validateStruct validates the struct, with the validation messages in the locale.
+// The invalid fields are named by the struct tag of the decoded format.
+func (sv *structValidator) validateStruct(a any, locale, fieldTag string) error {
+	// Only structs have validation tags, other bodies like maps, slices or strings are valid.
+	if t := reflect.TypeOf(a); t != nil && indirectType(t).Kind() != reflect.Struct {
 		return nil
 	}
 
-	err := v.Struct(a)
+	err := sv.validate.Struct(a)
 	if err != nil {
 		// this check is only needed when your code could produce an
 		// invalid value for validation such as interface with nil value
@@ -51,10 +252,14 @@ func validate(a any) error {
 		}
 		var errorsSummary []string
 		for _, err := range err.(validator.ValidationErrors) {
-			errorsSummary = append(errorsSummary, explainError(err))
+			name, pointer := fieldPath(reflect.TypeOf(a), err.StructNamespace(), fieldTag)
+			message := sv.message(err, locale, name)
+			errorsSummary = append(errorsSummary, message)
 			validationError.Errors = append(validationError.Errors, ErrorItem{
-				Name:   err.StructNamespace(),
-				Reason: err.Error(),
+				Name:    name,
+				Reason:  message,
+				Pointer: pointer,
+				In:      "body",
 				More: map[string]any{
 					"nsField": err.StructNamespace(),
 					"field":   err.StructField(),
@@ -71,3 +276,102 @@ func validate(a any) error {
 	}
 	return nil
 }
+
+// validate validates the struct with the validator of the read options, the default one if not set.
+func validate(a any, options readOptions) error {
+	sv := options.Validator
+	if sv == nil {
+		sv = defaultValidator
+	}
+	fieldTag := options.FieldTag
+	if fieldTag == "" {
+		fieldTag = "json"
+	}
+	return sv.validateStruct(a, options.Locale, fieldTag)
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// fieldPath returns the name of the field of the validator namespace (ex: MyInput.Address.Zip, MyInput.Items[0])
+// in the decoded format, with the names of the struct tag (ex: address.zip, items[0]), and its JSON Pointer (ex: /address/zip, /items/0).
+func fieldPath(t reflect.Type, namespace string, tag string) (string, string) {
+	t = indirectType(t)
+	namespace = strings.TrimPrefix(namespace, t.Name())
+
+	var name, pointer strings.Builder
+	for namespace != "" {
+		// Index of a slice, an array or a map
+		if namespace[0] == '[' {
+			end := strings.IndexByte(namespace, ']')
+			if end < 0 {
+				break
+			}
+			key := namespace[1:end]
+			namespace = namespace[end+1:]
+
+			name.WriteString("[" + key + "]")
+			pointer.WriteString("/" + jsonPointerEscaper.Replace(key))
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map) {
+				t = indirectType(t.Elem())
+			}
+			continue
+		}
+
+		namespace = strings.TrimPrefix(namespace, ".")
+		end := strings.IndexAny(namespace, ".[")
+		if end < 0 {
+			end = len(namespace)
+		}
+		fieldName := namespace[:end]
+		namespace = namespace[end:]
+
+		wireName := fieldName
+		if t != nil && t.Kind() == reflect.Struct {
+			field, ok := t.FieldByName(fieldName)
+			if !ok {
+				t = nil
+			} else {
+				t = indirectType(field.Type)
+				if isFlattened(field, tag) {
+					continue
+				}
+				wireName = fieldWireName(field, tag)
+			}
+		}
+
+		if name.Len() > 0 {
+			name.WriteString(".")
+		}
+		name.WriteString(wireName)
+		pointer.WriteString("/" + jsonPointerEscaper.Replace(wireName))
+	}
+
+	return name.String(), pointer.String()
+}
+
+// fieldWireName returns the name of the field in the format of the struct tag.
+func fieldWireName(field reflect.StructField, tag string) string {
+	name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if name == "" && tag == "csv" {
+		name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	}
+	if name != "" && name != "-" {
+		return name
+	}
+	if tag == "yaml" {
+		return strings.ToLower(field.Name)
+	}
+	return field.Name
+}
+
+// isFlattened reports whether the fields of the embedded struct are decoded as the fields of the parent struct.
+func isFlattened(field reflect.StructField, tag string) bool {
+	if !field.Anonymous {
+		return false
+	}
+	name, options, _ := strings.Cut(field.Tag.Get(tag), ",")
+	if tag == "yaml" {
+		return slices.Contains(strings.Split(options, ","), "inline")
+	}
+	return name == ""
+}
@@ -1,8 +1,16 @@
 package fuego
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/require"
 )
 
@@ -21,13 +29,216 @@ func TestValidate(t *testing.T) {
 		Email: "napoleon.bonaparte",
 	}
 
-	err := validate(me)
+	err := validate(me, readOptions{})
 	t.Log(err)
 	require.Error(t, err)
 
 	var errStructValidation HTTPError
 	require.ErrorAs(t, err, &errStructValidation)
 	require.Equal(t, 400, errStructValidation.StatusCode())
-	require.Equal(t, "Validation Error (400): Name should be max=10, Age should be min=18, Required is required, Email should be a valid email, ExternalID should be a valid UUID", errStructValidation.Error())
+	require.Equal(t, "Validation Error (400): Name must be a maximum of 10 characters in length, Age must be 18 or greater, Required is required, Email should be a valid email, ExternalID should be a valid UUID", errStructValidation.Error())
 	require.Len(t, errStructValidation.Errors, 5)
 }
+
+type skuStruct struct {
+	Ref  string `json:"ref" validate:"sku"`
+	Name string `json:"name" validate:"required"`
+}
+
+var skuRule = ValidationRule{
+	Tag: "sku",
+	Func: func(fl validator.FieldLevel) bool {
+		return strings.HasPrefix(fl.Field().String(), "SKU-")
+	},
+	Messages: map[string]string{
+		"en": "{0} should be a valid SKU",
+		"fr": "{0} doit être un SKU valide",
+	},
+	Schema: func(schema *openapi3.Schema, _ string) {
+		schema.Pattern = "^SKU-"
+	},
+}
+
+func TestValidationRules(t *testing.T) {
+	public := NewServer(
+		WithoutLogger(),
+		WithValidationRule(skuRule),
+	)
+	admin := NewServer(
+		WithoutLogger(),
+		WithValidationRule(ValidationRule{
+			Tag:  "sku",
+			Func: func(fl validator.FieldLevel) bool { return true },
+		}),
+	)
+	for _, s := range []*Server{public, admin} {
+		Post(s.RouterGroup(), "/products", func(c *ContextWithBody[skuStruct]) (skuStruct, error) {
+			return c.Body()
+		})
+	}
+
+	send := func(s *Server, body, language string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept-Language", language)
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	t.Run("validates with the rules of each server", func(t *testing.T) {
+		w := send(public, `{"ref":"ABC","name":"Shoe"}`, "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Contains(t, w.Body.String(), "ref should be a valid SKU")
+
+		w = send(admin, `{"ref":"ABC","name":"Shoe"}`, "")
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("translates the messages of the rules", func(t *testing.T) {
+		w := send(public, `{"ref":"ABC","name":"Shoe"}`, "fr-CA,fr;q=0.9")
+		require.Contains(t, w.Body.String(), "ref doit être un SKU valide")
+
+		w = send(public, `{"ref":"ABC","name":"Shoe"}`, "de")
+		require.Contains(t, w.Body.String(), "ref should be a valid SKU")
+	})
+
+	t.Run("translates the messages of the builtin tags", func(t *testing.T) {
+		w := send(public, `{"ref":"SKU-1"}`, "fr")
+		require.Contains(t, w.Body.String(), "name est un champ obligatoire")
+
+		w = send(public, `{"ref":"SKU-1"}`, "en-US")
+		require.Contains(t, w.Body.String(), "name is required")
+	})
+
+	t.Run("documents the rules", func(t *testing.T) {
+		public.OutputOpenAPISpec()
+		schema := public.OpenApiSpec.Components.Schemas["SkuStruct"].Value
+		require.Equal(t, "^SKU-", schema.Properties["ref"].Value.Pattern)
+	})
+
+	t.Run("translates the messages of a validator shared by servers", func(t *testing.T) {
+		shared := validator.New()
+		servers := []*Server{
+			NewServer(WithoutLogger(), WithValidator(shared)),
+			NewServer(WithoutLogger(), WithValidator(shared)),
+		}
+		for _, s := range servers {
+			Post(s.RouterGroup(), "/products", func(c *ContextWithBody[auditFields]) (auditFields, error) {
+				return c.Body()
+			})
+		}
+
+		var wg sync.WaitGroup
+		for _, s := range servers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				w := send(s, `{}`, "fr")
+				require.Contains(t, w.Body.String(), "author est un champ obligatoire")
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("keeps the rules when replacing the validator", func(t *testing.T) {
+		s := NewServer(
+			WithoutLogger(),
+			WithValidationRule(skuRule),
+			WithValidator(validator.New()),
+		)
+		Post(s.RouterGroup(), "/products", func(c *ContextWithBody[skuStruct]) (skuStruct, error) {
+			return c.Body()
+		})
+
+		w := send(s, `{"ref":"ABC","name":"Shoe"}`, "")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
+
+type zipAddress struct {
+	Zip string `json:"zip" xml:"code" validate:"len=5"`
+}
+
+type auditFields struct {
+	Author string `json:"author" validate:"required"`
+}
+
+type orderInput struct {
+	auditFields
+	Address zipAddress            `json:"address" xml:"address"`
+	Lines   []zipAddress          `json:"lines" xml:"line" validate:"dive"`
+	Labels  map[string]zipAddress `json:"labels" xml:"-"`
+}
+
+func TestFieldPath(t *testing.T) {
+	orderType := reflect.TypeFor[orderInput]()
+
+	tests := []struct {
+		namespace string
+		tag       string
+		name      string
+		pointer   string
+	}{
+		{"orderInput.Address.Zip", "json", "address.zip", "/address/zip"},
+		{"orderInput.Address.Zip", "xml", "address.code", "/address/code"},
+		{"orderInput.Address.Zip", "yaml", "address.zip", "/address/zip"},
+		{"orderInput.Lines[2].Zip", "json", "lines[2].zip", "/lines/2/zip"},
+		{"orderInput.Labels[a/b].Zip", "json", "labels[a/b].zip", "/labels/a~1b/zip"},
+		{"orderInput.auditFields.Author", "json", "author", "/author"},
+		{"orderInput.Unknown", "json", "Unknown", "/Unknown"},
+	}
+	for _, tt := range tests {
+		name, pointer := fieldPath(orderType, tt.namespace, tt.tag)
+		require.Equal(t, tt.name, name, tt.namespace)
+		require.Equal(t, tt.pointer, pointer, tt.namespace)
+	}
+}
+
+func TestValidationErrorNames(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	Post(s.RouterGroup(), "/orders", func(c *ContextWithBody[orderInput]) (orderInput, error) {
+		return c.Body()
+	})
+
+	send := func(contentType, body string) HTTPError {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		s.ServeHTTP(w, r)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var httpError HTTPError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &httpError))
+		return httpError
+	}
+
+	t.Run("json", func(t *testing.T) {
+		httpError := send("application/json", `{"author":"me","address":{"zip":"123"},"lines":[{"zip":"12345"},{"zip":"1"}]}`)
+
+		require.Len(t, httpError.Errors, 2)
+		require.Equal(t, "address.zip", httpError.Errors[0].Name)
+		require.Equal(t, "/address/zip", httpError.Errors[0].Pointer)
+		require.Equal(t, "body", httpError.Errors[0].In)
+		require.Equal(t, "lines[1].zip", httpError.Errors[1].Name)
+		require.Equal(t, "/lines/1/zip", httpError.Errors[1].Pointer)
+		require.Equal(t, "address.zip must be 5 characters in length", httpError.Errors[0].Reason)
+		require.Contains(t, httpError.Detail, "lines[1].zip must be 5 characters in length")
+	})
+
+	t.Run("xml", func(t *testing.T) {
+		httpError := send("application/xml", `<orderInput><Author>me</Author><address><code>123</code></address></orderInput>`)
+
+		require.Len(t, httpError.Errors, 1)
+		require.Equal(t, "address.code", httpError.Errors[0].Name)
+		require.Equal(t, "/address/code", httpError.Errors[0].Pointer)
+		require.Equal(t, "address.code must be 5 characters in length", httpError.Errors[0].Reason)
+	})
+}
+
+func TestRenameField(t *testing.T) {
+	require.Equal(t, "name est un champ obligatoire", renameField("Name est un champ obligatoire", "Name", "name"))
+	require.Equal(t, "address.zip长度必须是5个字符", renameField("Zip长度必须是5个字符", "Zip", "address.zip"))
+	require.Equal(t, "Zipcode is required", renameField("Zipcode is required", "Zip", "zip"))
+}
@@ -0,0 +1,637 @@
+package fuego
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha1" // #nosec G505 (required by RFC 6455 for the handshake, not used for security)
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+// WebSocket close status codes, as defined in RFC 6455 section 7.4.1.
+const (
+	WebSocketCloseNormal          = 1000
+	WebSocketCloseGoingAway       = 1001
+	WebSocketCloseProtocolError   = 1002
+	WebSocketCloseUnsupportedData = 1003
+	WebSocketCloseInvalidPayload  = 1007
+	WebSocketClosePolicyViolation = 1008
+	WebSocketCloseMessageTooBig   = 1009
+	WebSocketCloseInternalError   = 1011
+)
+
+// WebSocket frame opcodes, as defined in RFC 6455 section 5.2.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// wsAcceptGUID is concatenated to the client key to compute the handshake answer (RFC 6455 section 1.3).
+const wsAcceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsReadBuffer is the number of messages received in the background before being read with [WebSocketConn.Read].
+// Once it is full, the frames of the client are no longer read until the controller reads a message.
+const wsReadBuffer = 16
+
+// WebSocketCloseError is returned by [WebSocketConn.Read] when the client closes the connection
+// with another status than a normal closure.
+type WebSocketCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *WebSocketCloseError) Error() string {
+	return fmt.Sprintf("websocket closed with status %d: %s", e.Code, e.Reason)
+}
+
+// WebSocketConn is a WebSocket connection reading messages of type In and writing messages of type Out,
+// encoded with the codec negotiated during the handshake (see [WebSocket]).
+// The frames of the client are read in the background: control frames are answered,
+// and the context of the connection is canceled as soon as the client leaves, even if the controller only writes.
+// Read must not be called concurrently, but Write can be called concurrently with Read and with itself.
+type WebSocketConn[In, Out any] struct {
+	conn      net.Conn
+	rw        *bufio.ReadWriter
+	request   *http.Request // Upgrade request, used by the codec
+	ctx       context.Context
+	cancel    context.CancelFunc
+	readLimit int64
+	options   readOptions
+
+	messages chan []byte // Messages read in the background, closed once the connection is closed or lost
+	readErr  error       // Reason the messages channel was closed, set before closing it
+
+	codec     Codec
+	mediaType string
+	opcode    byte // Text for the textual media types, binary otherwise
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closed    bool // Set once a close frame has been sent, protected by writeMu
+}
+
+// Read returns the next message sent by the client, decoded with the negotiated codec,
+// transformed and validated like request bodies.
+// Ping frames are answered automatically, even when Read is not called.
+// When the client closes the connection normally, it returns [io.EOF].
+// Other closures return a [*WebSocketCloseError].
+// Once the connection is closed or lost, the context of the connection is canceled.
+// Messages that cannot be decoded return a [BadRequestError], the connection can still be used.
+func (c *WebSocketConn[In, Out]) Read() (In, error) {
+	var msg In
+
+	payload, ok := <-c.messages
+	if !ok {
+		return msg, c.readErr
+	}
+
+	r := c.request.Clone(c.ctx)
+	r.Header.Set("Content-Type", c.mediaType)
+	r.Body = io.NopCloser(bytes.NewReader(payload))
+	r.ContentLength = int64(len(payload))
+
+	return decodeBody[In](r, c.codec, c.mediaType, c.options)
+}
+
+// Write sends the message to the client, encoded with the negotiated codec,
+// in a text message for the textual media types and in a binary message otherwise.
+// If Out implements [OutTransformer], it is transformed before being sent.
+func (c *WebSocketConn[In, Out]) Write(msg Out) error {
+	var err error
+	if reflect.TypeOf(msg) != nil {
+		msg, err = transformOut(c.ctx, msg)
+		if err != nil {
+			return err
+		}
+	}
+
+	w := &wsMessageWriter{header: http.Header{}}
+	err = c.codec.Encode(w, c.request, msg)
+	if err != nil {
+		return fmt.Errorf("cannot encode websocket message: %w", err)
+	}
+
+	return c.writeFrame(c.opcode, w.body.Bytes())
+}
+
+// Close sends a close frame with the given status code and reason, then closes the connection.
+// It is called automatically with [WebSocketCloseNormal] when the controller returns.
+func (c *WebSocketConn[In, Out]) Close(code int, reason string) error {
+	var err error
+	c.closeOnce.Do(func() {
+		payload := make([]byte, 2, 2+len(reason))
+		binary.BigEndian.PutUint16(payload, uint16(code)) // #nosec G115 (close codes fit in 16 bits)
+		payload = append(payload, truncateCloseReason(reason)...)
+
+		_ = c.writeFrame(wsOpClose, payload)
+		err = c.conn.Close()
+		c.cancel()
+	})
+	return err
+}
+
+// Context returns the context of the connection, derived from the one of the upgrade request.
+// It is canceled when the connection is closed, when the client sends a close frame, or when the connection is lost.
+// Unlike the context of the upgrade request, it is the one to watch to stop working once the client leaves.
+func (c *WebSocketConn[In, Out]) Context() context.Context {
+	return c.ctx
+}
+
+// readLoop reads the messages of the client in the background, until the connection is closed or lost.
+// It then cancels the context of the connection, and closes the messages channel with the reason.
+func (c *WebSocketConn[In, Out]) readLoop() {
+	defer c.cancel()
+	defer close(c.messages)
+
+	for {
+		payload, err := c.readMessage()
+		if err != nil {
+			c.readErr = err
+			return
+		}
+
+		select {
+		case c.messages <- payload:
+		case <-c.ctx.Done():
+			c.readErr = net.ErrClosed
+			return
+		}
+	}
+}
+
+// readMessage reads the frames of the next data message, answering control frames.
+func (c *WebSocketConn[In, Out]) readMessage() ([]byte, error) {
+	var message []byte
+	started := false
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsOpPing:
+			err = c.writeFrame(wsOpPong, payload)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpClose:
+			return nil, c.handleClose(payload)
+		case wsOpText, wsOpBinary:
+			if started {
+				return nil, c.protocolError("new message before the end of the fragmented one")
+			}
+			started = true
+		case wsOpContinuation:
+			if !started {
+				return nil, c.protocolError("continuation frame without a message")
+			}
+		default:
+			return nil, c.protocolError(fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if int64(len(message)+len(payload)) > c.readLimit {
+			_ = c.Close(WebSocketCloseMessageTooBig, "message too big")
+			return nil, &WebSocketCloseError{Code: WebSocketCloseMessageTooBig, Reason: "message too big"}
+		}
+		message = append(message, payload...)
+
+		if fin {
+			return message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame and unmasks its payload.
+func (c *WebSocketConn[In, Out]) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	header := make([]byte, 2)
+	_, err = io.ReadFull(c.rw, header)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.protocolError("reserved bits set without negotiated extension")
+	}
+
+	masked := header[1]&0x80 != 0
+	if !masked {
+		return false, 0, nil, c.protocolError("client frames must be masked")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(c.rw, extended)
+		length = uint64(binary.BigEndian.Uint16(extended))
+	case 127:
+		extended := make([]byte, 8)
+		_, err = io.ReadFull(c.rw, extended)
+		length = binary.BigEndian.Uint64(extended)
+	}
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	if opcode >= wsOpClose && (length > 125 || !fin) {
+		return false, 0, nil, c.protocolError("invalid control frame")
+	}
+
+	if length > uint64(c.readLimit) { // #nosec G115 (the read limit is positive)
+		_ = c.Close(WebSocketCloseMessageTooBig, "message too big")
+		return false, 0, nil, &WebSocketCloseError{Code: WebSocketCloseMessageTooBig, Reason: "message too big"}
+	}
+
+	mask := make([]byte, 4)
+	_, err = io.ReadFull(c.rw, mask)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	payload = make([]byte, length)
+	_, err = io.ReadFull(c.rw, payload)
+	if err != nil {
+		return false, 0, nil, err
+	}
+
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame writes a single unmasked frame, as servers must not mask their frames.
+func (c *WebSocketConn[In, Out]) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return net.ErrClosed
+	}
+	if opcode == wsOpClose {
+		c.closed = true
+	}
+
+	header := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length <= 125:
+		header = append(header, byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	_, err := c.rw.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = c.rw.Write(payload)
+	if err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// handleClose answers a close frame sent by the client.
+func (c *WebSocketConn[In, Out]) handleClose(payload []byte) error {
+	code := WebSocketCloseNormal
+	reason := ""
+	if len(payload) >= 2 {
+		code = int(binary.BigEndian.Uint16(payload))
+		reason = string(payload[2:])
+	}
+
+	_ = c.Close(code, "")
+
+	if code == WebSocketCloseNormal || code == WebSocketCloseGoingAway {
+		return io.EOF
+	}
+	return &WebSocketCloseError{Code: code, Reason: reason}
+}
+
+func (c *WebSocketConn[In, Out]) protocolError(reason string) error {
+	_ = c.Close(WebSocketCloseProtocolError, reason)
+	return &WebSocketCloseError{Code: WebSocketCloseProtocolError, Reason: reason}
+}
+
+// truncateCloseReason keeps the close reason within the 123 bytes allowed in a control frame, on a rune boundary.
+func truncateCloseReason(reason string) string {
+	if len(reason) <= 123 {
+		return reason
+	}
+	reason = reason[:123]
+	for !utf8.ValidString(reason) {
+		reason = reason[:len(reason)-1]
+	}
+	return reason
+}
+
+// WebSocket registers a route upgrading GET requests to a WebSocket connection,
+// reading messages of type In and writing messages of type Out.
+// The messages are encoded with the codec of the first subprotocol requested by the client naming a media type,
+// like "json", "msgpack" or "application/cbor", which is then selected. Without such subprotocol,
+// the codec is negotiated with the Accept header of the handshake, and defaults to JSON (see [WithCodec]).
+// Cross-origin handshakes are rejected with a 403 Forbidden error, unless allowed with [WithWebSocketOrigins].
+// The controller runs once the connection is upgraded. When it returns, the connection is closed:
+// normally if the error is nil, or with a status code derived from the error
+// (1008 for client errors, 1011 otherwise) after passing it to the server's error handler.
+// Requests that are not valid WebSocket handshakes get an error response through the usual error pipeline.
+// For example:
+//
+//	fuego.WebSocket(s.RouterGroup(), "/ws/dashboard", func(c fuego.ContextNoBody, conn *fuego.WebSocketConn[Subscription, Metrics]) error {
+//		sub, err := conn.Read()
+//		if err != nil {
+//			return err
+//		}
+//		for metrics := range watch(conn.Context(), sub) {
+//			if err := conn.Write(metrics); err != nil {
+//				return err
+//			}
+//		}
+//		return nil
+//	})
+//
+// The route is documented in the OpenAPI spec with a 101 response and the x-websocket extension,
+// holding the schemas of the messages in both directions.
+func WebSocket[In, Out any](s *RouterGroup, path string, controller func(c ContextNoBody, conn *WebSocketConn[In, Out]) error, middlewares ...gin.HandlerFunc) Route {
+	server := s.server
+
+	r := Register(s, Route{
+		Method: http.MethodGet,
+		Path:   path,
+	}, func(c *gin.Context) {
+		ctx := server.contextNoBody(c.Writer, c.Request, c)
+
+		conn, err := upgradeWebSocket[In, Out](c.Writer, c.Request, ctx.readOptions, server.webSocketOrigins)
+		if err != nil {
+			server.serializeError(c.Writer, c.Request, err)
+			return
+		}
+
+		err = controller(ctx, conn)
+		if err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+			_ = conn.Close(WebSocketCloseNormal, "")
+			return
+		}
+
+		var closeError *WebSocketCloseError
+		if errors.As(err, &closeError) {
+			_ = conn.Close(closeError.Code, closeError.Reason)
+			return
+		}
+
+		err = server.handleError(ctx, err)
+		code := WebSocketCloseInternalError
+		var errorWithStatus ErrorWithStatus
+		if errors.As(err, &errorWithStatus) && errorWithStatus.StatusCode() < http.StatusInternalServerError {
+			code = WebSocketClosePolicyViolation
+		}
+		_ = conn.Close(code, err.Error())
+	}, middlewares...)
+
+	inSchema := schemaTagFromType(server, *new(In))
+	outSchema := schemaTagFromType(server, *new(Out))
+	r.Operation.Extensions = map[string]any{
+		"x-websocket": map[string]any{
+			"in":  &inSchema.SchemaRef,
+			"out": &outSchema.SchemaRef,
+		},
+	}
+	r.Operation.AddResponse(http.StatusSwitchingProtocols, openapi3.NewResponse().WithDescription("Switching Protocols to WebSocket"))
+
+	return r
+}
+
+// upgradeWebSocket validates the handshake (RFC 6455 section 4.2), negotiates the codec and hijacks the connection.
+func upgradeWebSocket[In, Out any](w http.ResponseWriter, r *http.Request, options readOptions, origins []string) (*WebSocketConn[In, Out], error) {
+	if r.Method != http.MethodGet {
+		return nil, BadRequestError{
+			Title:  "Invalid WebSocket Handshake",
+			Detail: "websocket handshake must use the GET method",
+			Err:    errors.New("websocket handshake must use the GET method"),
+		}
+	}
+
+	if !headerContainsToken(r.Header, "Connection", "upgrade") || !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return nil, HTTPError{
+			Status: http.StatusUpgradeRequired,
+			Title:  "Upgrade Required",
+			Detail: "this route only accepts WebSocket connections",
+		}
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return nil, HTTPError{
+			Status: http.StatusUpgradeRequired,
+			Title:  "Unsupported WebSocket Version",
+			Detail: "only the WebSocket version 13 is supported",
+		}
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	decodedKey, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(decodedKey) != 16 {
+		return nil, BadRequestError{
+			Title:  "Invalid WebSocket Handshake",
+			Detail: "invalid Sec-WebSocket-Key header",
+			Err:    errors.New("invalid Sec-WebSocket-Key header"),
+		}
+	}
+
+	if !webSocketOriginAllowed(r, origins) {
+		return nil, ForbiddenError{
+			Title:  "Cross-Origin WebSocket Handshake",
+			Detail: "websocket connections from " + r.Header.Get("Origin") + " are not allowed",
+			Err:    errors.New("websocket handshake from a disallowed origin"),
+		}
+	}
+
+	codec, mediaType, subprotocol, err := webSocketCodec(r, reflect.TypeFor[In](), reflect.TypeFor[Out]())
+	if err != nil {
+		return nil, err
+	}
+
+	netConn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("cannot hijack the connection for the websocket: %w", err)
+	}
+
+	// The deadlines of the HTTP server do not apply to the long-lived connection.
+	_ = netConn.SetDeadline(time.Time{})
+
+	hash := sha1.Sum([]byte(key + wsAcceptGUID)) // #nosec G401 (required by RFC 6455)
+	handshake := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n"
+	if subprotocol != "" {
+		handshake += "Sec-WebSocket-Protocol: " + subprotocol + "\r\n"
+	}
+	_, err = rw.WriteString(handshake + "\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		_ = netConn.Close()
+		return nil, fmt.Errorf("cannot write the websocket handshake: %w", err)
+	}
+
+	readLimit := options.MaxBodySize
+	if readLimit <= 0 {
+		readLimit = maxBodySize
+	}
+
+	opcode := byte(wsOpBinary)
+	if isTextMediaType(mediaType) {
+		opcode = wsOpText
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+
+	conn := &WebSocketConn[In, Out]{
+		conn:      netConn,
+		rw:        rw,
+		request:   r,
+		ctx:       ctx,
+		cancel:    cancel,
+		readLimit: readLimit,
+		options:   options,
+		messages:  make(chan []byte, wsReadBuffer),
+		codec:     codec,
+		mediaType: mediaType,
+		opcode:    opcode,
+	}
+	go conn.readLoop()
+
+	return conn, nil
+}
+
+// webSocketOriginAllowed reports whether the handshake comes from the same origin as the requested host,
+// or from one of the allowed origins. Clients that are not browsers do not send the Origin header, and are allowed.
+func webSocketOriginAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// webSocketCodec returns the codec of the messages, able to decode In and encode Out, with its media type.
+// The first subprotocol requested by the client naming the media type of such codec is selected,
+// either in full or by its subtype, like "application/json" or "json".
+// Otherwise, the codec is negotiated with the Accept header of the handshake.
+func webSocketCodec(r *http.Request, in, out reflect.Type) (codec Codec, mediaType, subprotocol string, err error) {
+	codecs := codecRegistry{}
+	offers := []string{}
+	for _, c := range codecsFromRequest(r) {
+		if canDecode(c, in) && canEncode(c, out) {
+			codecs = append(codecs, c)
+			offers = appendMissing(offers, c.MediaTypes()...)
+		}
+	}
+
+	for _, value := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			protocol = strings.TrimSpace(protocol)
+			named := protocol
+			if !strings.Contains(named, "/") {
+				named = "application/" + named
+			}
+			if c := codecs.lookup(named); c != nil {
+				return c, strings.ToLower(named), protocol, nil
+			}
+		}
+	}
+
+	accept := r.Header.Get("Accept")
+	acceptable := negotiate(accept, offers)
+	if len(acceptable) == 0 {
+		return nil, "", "", NotAcceptableError{
+			Title:  "Not Acceptable",
+			Detail: "cannot exchange messages in any of the accepted media types " + accept + ", available media types are " + strings.Join(offers, ", "),
+			Err:    errors.New("no acceptable media type in " + accept),
+		}
+	}
+
+	return codecs.lookup(acceptable[0]), acceptable[0], "", nil
+}
+
+// isTextMediaType reports whether the media type is textual, and its messages sent as text frames.
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	for _, suffix := range []string{"json", "xml", "yaml"} {
+		if strings.HasSuffix(mediaType, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// wsMessageWriter collects the message written by a codec.
+type wsMessageWriter struct {
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *wsMessageWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *wsMessageWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *wsMessageWriter) WriteHeader(int) {}
+
+// headerContainsToken reports whether the comma-separated header contains the token, case-insensitively.
+func headerContainsToken(header http.Header, name, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, part := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package fuego
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type wsSubscription struct {
+	Topic string `json:"topic" validate:"required"`
+}
+
+type wsUpdate struct {
+	Topic string `json:"topic"`
+	Value int    `json:"value"`
+}
+
+// wsTestClient is a minimal WebSocket client, only for testing.
+type wsTestClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialWebSocket opens a WebSocket connection to /ws, with the additional header lines.
+func dialWebSocket(t *testing.T, url string, headers ...string) *wsTestClient {
+	t.Helper()
+
+	client, resp := handshakeWebSocket(t, url, headers...)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+
+	return client
+}
+
+func handshakeWebSocket(t *testing.T, url string, headers ...string) (*wsTestClient, *http.Response) {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	request := "GET /ws HTTP/1.1\r\n" +
+		"Host: localhost\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n"
+	for _, header := range headers {
+		request += header + "\r\n"
+	}
+	_, err = conn.Write([]byte(request + "\r\n"))
+	require.NoError(t, err)
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	require.NoError(t, err)
+
+	return &wsTestClient{conn: conn, r: r}, resp
+}
+
+func (c *wsTestClient) send(t *testing.T, opcode byte, fin bool, payload []byte) {
+	t.Helper()
+
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	_, err := c.conn.Write(frame)
+	require.NoError(t, err)
+}
+
+func (c *wsTestClient) receive(t *testing.T) (byte, []byte) {
+	t.Helper()
+
+	header := make([]byte, 2)
+	_, err := io.ReadFull(c.r, header)
+	require.NoError(t, err)
+	require.Zero(t, header[1]&0x80, "server frames must not be masked")
+
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		extended := make([]byte, 2)
+		_, err = io.ReadFull(c.r, extended)
+		require.NoError(t, err)
+		length = int(binary.BigEndian.Uint16(extended))
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(c.r, payload)
+	require.NoError(t, err)
+
+	return header[0] & 0x0F, payload
+}
+
+func TestWebSocket(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	controllerErr := make(chan error, 1)
+	WebSocket(s.RouterGroup(), "/ws", func(c ContextNoBody, conn *WebSocketConn[wsSubscription, wsUpdate]) error {
+		for i := 1; ; i++ {
+			sub, err := conn.Read()
+			var badRequest BadRequestError
+			if errors.As(err, &badRequest) {
+				return err
+			}
+			if err != nil {
+				controllerErr <- err
+				return err
+			}
+
+			err = conn.Write(wsUpdate{Topic: sub.Topic, Value: i})
+			if err != nil {
+				return err
+			}
+		}
+	})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	t.Run("exchanges typed messages", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL)
+
+		client.send(t, wsOpText, true, []byte(`{"topic":"cpu"}`))
+		opcode, payload := client.receive(t)
+		require.Equal(t, byte(wsOpText), opcode)
+		require.JSONEq(t, `{"topic":"cpu","value":1}`, string(payload))
+
+		// Fragmented message with a ping in the middle
+		client.send(t, wsOpText, false, []byte(`{"topic":`))
+		client.send(t, wsOpPing, true, []byte("ping"))
+		opcode, payload = client.receive(t)
+		require.Equal(t, byte(wsOpPong), opcode)
+		require.Equal(t, "ping", string(payload))
+		client.send(t, wsOpContinuation, true, []byte(`"memory"}`))
+		_, payload = client.receive(t)
+		require.JSONEq(t, `{"topic":"memory","value":2}`, string(payload))
+
+		client.send(t, wsOpClose, true, []byte{0x03, 0xE8})
+		opcode, payload = client.receive(t)
+		require.Equal(t, byte(wsOpClose), opcode)
+		require.Equal(t, WebSocketCloseNormal, int(binary.BigEndian.Uint16(payload)))
+		require.ErrorIs(t, <-controllerErr, io.EOF)
+	})
+
+	t.Run("closes with a policy violation on invalid messages", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL)
+
+		client.send(t, wsOpText, true, []byte(`{}`))
+		opcode, payload := client.receive(t)
+		require.Equal(t, byte(wsOpClose), opcode)
+		require.Equal(t, WebSocketClosePolicyViolation, int(binary.BigEndian.Uint16(payload)))
+		require.Contains(t, string(payload[2:]), "topic")
+	})
+
+	t.Run("negotiates the codec with the subprotocol", func(t *testing.T) {
+		client, resp := handshakeWebSocket(t, server.URL, "Sec-WebSocket-Protocol: chat, xml")
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		require.Equal(t, "xml", resp.Header.Get("Sec-WebSocket-Protocol"))
+
+		client.send(t, wsOpText, true, []byte(`<wsSubscription><Topic>disk</Topic></wsSubscription>`))
+		opcode, payload := client.receive(t)
+		require.Equal(t, byte(wsOpText), opcode)
+		require.Equal(t, `<wsUpdate><Topic>disk</Topic><Value>1</Value></wsUpdate>`, string(payload))
+	})
+
+	t.Run("negotiates the codec with the Accept header", func(t *testing.T) {
+		client, resp := handshakeWebSocket(t, server.URL, "Accept: application/cbor")
+		require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+		require.Empty(t, resp.Header.Get("Sec-WebSocket-Protocol"))
+
+		client.send(t, wsOpBinary, true, []byte{0xA1, 0x65, 't', 'o', 'p', 'i', 'c', 0x63, 'c', 'p', 'u'})
+		opcode, payload := client.receive(t)
+		require.Equal(t, byte(wsOpBinary), opcode)
+
+		var update wsUpdate
+		require.NoError(t, CBORCodec{}.Decode(httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(payload)), &update, DecodeOptions{}))
+		require.Equal(t, wsUpdate{Topic: "cpu", Value: 1}, update)
+
+		_, resp = handshakeWebSocket(t, server.URL, "Accept: text/csv")
+		require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+	})
+
+	t.Run("rejects cross-origin handshakes", func(t *testing.T) {
+		_, resp := handshakeWebSocket(t, server.URL, "Origin: https://evil.example.com")
+		require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		dialWebSocket(t, server.URL, "Origin: http://localhost")
+	})
+
+	t.Run("rejects plain HTTP requests", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/ws")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	})
+
+	t.Run("documents the messages", func(t *testing.T) {
+		operation := s.OpenApiSpec.Paths.Find("/ws").Get
+		require.NotNil(t, operation)
+		require.NotNil(t, operation.Responses.Status(http.StatusSwitchingProtocols))
+
+		extension, ok := operation.Extensions["x-websocket"].(map[string]any)
+		require.True(t, ok)
+		require.Contains(t, extension, "in")
+		require.Contains(t, extension, "out")
+
+		spec, err := s.OpenApiSpec.MarshalJSON()
+		require.NoError(t, err)
+		require.Contains(t, string(spec), `"x-websocket":{"in":{"$ref":"#/components/schemas/WsSubscription"},"out":{"$ref":"#/components/schemas/WsUpdate"}}`)
+	})
+}
+
+func TestWebSocketOrigins(t *testing.T) {
+	s := NewServer(WithoutLogger(), WithWebSocketOrigins("https://app.example.com"))
+	WebSocket(s.RouterGroup(), "/ws", func(c ContextNoBody, conn *WebSocketConn[wsSubscription, wsUpdate]) error {
+		return nil
+	})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	dialWebSocket(t, server.URL, "Origin: https://app.example.com")
+
+	_, resp := handshakeWebSocket(t, server.URL, "Origin: https://other.example.com")
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestWebSocketContext(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	canceled := make(chan error, 1)
+	WebSocket(s.RouterGroup(), "/ws", func(c ContextNoBody, conn *WebSocketConn[wsSubscription, wsUpdate]) error {
+		go func() {
+			for {
+				if _, err := conn.Read(); err != nil {
+					return
+				}
+			}
+		}()
+
+		<-conn.Context().Done()
+		canceled <- conn.Context().Err()
+		return nil
+	})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	client := dialWebSocket(t, server.URL)
+	require.NoError(t, client.conn.Close())
+
+	select {
+	case err := <-canceled:
+		require.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context of the connection is not canceled when the client disconnects")
+	}
+}
+
+func TestWebSocketWriteOnly(t *testing.T) {
+	s := NewServer(WithoutLogger())
+
+	canceled := make(chan error, 1)
+	WebSocket(s.RouterGroup(), "/ws", func(c ContextNoBody, conn *WebSocketConn[wsSubscription, wsUpdate]) error {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+
+		for i := 1; ; i++ {
+			select {
+			case <-conn.Context().Done():
+				canceled <- conn.Context().Err()
+				return nil
+			case <-ticker.C:
+				// Writes succeed until the close frame is answered, only the context tells the client left.
+				_ = conn.Write(wsUpdate{Topic: "cpu", Value: i})
+			}
+		}
+	})
+
+	server := httptest.NewServer(s)
+	defer server.Close()
+
+	waitCanceled := func(t *testing.T) {
+		t.Helper()
+		select {
+		case err := <-canceled:
+			require.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the context of the connection is not canceled when the client leaves")
+		}
+	}
+
+	t.Run("answers pings and cancels the context on close frames", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL)
+		defer client.conn.Close()
+		require.NoError(t, client.conn.SetDeadline(time.Now().Add(5*time.Second)))
+
+		opcode, _ := client.receive(t)
+		require.Equal(t, byte(wsOpText), opcode)
+
+		client.send(t, wsOpPing, true, []byte("ping"))
+		for {
+			opcode, payload := client.receive(t)
+			if opcode == wsOpPong {
+				require.Equal(t, "ping", string(payload))
+				break
+			}
+		}
+
+		client.send(t, wsOpClose, true, binary.BigEndian.AppendUint16(nil, WebSocketCloseGoingAway))
+		waitCanceled(t)
+	})
+
+	t.Run("cancels the context when the connection is lost", func(t *testing.T) {
+		client := dialWebSocket(t, server.URL)
+
+		opcode, _ := client.receive(t)
+		require.Equal(t, byte(wsOpText), opcode)
+
+		require.NoError(t, client.conn.Close())
+		waitCanceled(t)
+	})
+}